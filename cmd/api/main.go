@@ -8,13 +8,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ayush/ORBIT/internal/cache"
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/config"
 	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/jobs"
 	"github.com/ayush/ORBIT/internal/middleware"
+	"github.com/ayush/ORBIT/internal/queue"
+	"github.com/ayush/ORBIT/internal/service"
 	"github.com/ayush/ORBIT/routes"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func main() {
@@ -22,9 +26,18 @@ func main() {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
-	// Database configuration
-	dsn := "host=localhost user=postgres password=postgres dbname=Orbit port=5432 sslmode=disable TimeZone=Asia/Kolkata"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	cfg := config.Load()
+
+	// Institution timezone and academic week used for every day and week boundary
+	cal, err := calendar.New(cfg.Timezone, cfg.WeekStartDay, cfg.AcademicYearStart, cfg.ProgrammeYears)
+	if err != nil {
+		logger.Fatal("invalid calendar configuration",
+			zap.Error(err),
+		)
+	}
+
+	// Initialize database connection
+	db, err := database.InitDB(cfg)
 	if err != nil {
 		logger.Fatal("failed to connect to database",
 			zap.Error(err),
@@ -33,6 +46,23 @@ func main() {
 
 	// Initialize database
 	studentDB := database.NewStudentDB(db)
+	redisCache := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+
+	// Leaderboard changes published by the worker, fanned out to stream clients
+	leaderboardStream := service.NewLeaderboardStream(redisCache, logger)
+	leaderboardStream.Start()
+
+	// Header aliases are needed here too for dry-run validation of uploads
+	aliases, err := service.LoadColumnAliases(cfg.ImportAliasesFile)
+	if err != nil {
+		logger.Fatal("failed to load import column aliases",
+			zap.Error(err),
+		)
+	}
+
+	// The API only enqueues jobs; cmd/worker consumes them
+	jobQueue := queue.New(database.NewJobDB(db), logger, queue.DefaultConfig())
+	refresher := jobs.NewQueuedRefreshRequester(jobQueue, jobs.DefaultRefreshQueueConfig().OnDemandCooldown, logger)
 
 	// Initialize Gin router
 	gin.SetMode(gin.ReleaseMode)
@@ -45,16 +75,18 @@ func main() {
 	router.Use(middleware.CORS())
 
 	// Setup routes
-	routes.SetupRoutes(router, studentDB)
+	routes.SetupRoutes(router, studentDB, cal, redisCache, leaderboardStream, aliases, refresher, jobQueue)
 
 	// Configure server
 	srv := &http.Server{
-		Addr:         ":8080",
+		Addr:         ":" + cfg.ServerPort,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Open streams would otherwise hold up Shutdown until its deadline
+	srv.RegisterOnShutdown(leaderboardStream.Stop)
 
 	// Start server in a goroutine
 	go func() {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"github.com/ayush/ORBIT/internal/config"
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/jobs"
+	"github.com/ayush/ORBIT/internal/queue"
+	"go.uber.org/zap"
+)
+
+// backfill-contests queues a contest history sync for every student with a
+// LeetCode ID. Older syncs dated every contest by the time of the sync and
+// kept contests the student never entered; each sync replaces a student's
+// history with the attended contests dated by their start time.
+func main() {
+	// Initialize logger
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg := config.Load()
+
+	// Initialize database connection
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		logger.Fatal("failed to connect to database",
+			zap.Error(err),
+		)
+	}
+
+	ctx := context.Background()
+	students, err := database.NewStudentDB(db).StudentRepository().ListRefreshCandidates(ctx)
+	if err != nil {
+		logger.Fatal("failed to list students",
+			zap.Error(err),
+		)
+	}
+
+	// cmd/worker runs the queued syncs at its usual LeetCode request rate
+	jobQueue := queue.New(database.NewJobDB(db), logger, queue.DefaultConfig())
+	queued := 0
+	for _, student := range students {
+		if _, err := jobQueue.Enqueue(ctx, jobs.TypeSyncContests, jobs.StudentPayload{StudentID: student.StudentID}); err != nil {
+			logger.Error("failed to queue contest sync",
+				zap.Uint("student_id", student.StudentID),
+				zap.Error(err),
+			)
+			continue
+		}
+		queued++
+	}
+
+	logger.Info("Contest history backfill queued",
+		zap.Int("students", len(students)),
+		zap.Int("queued", queued),
+	)
+}
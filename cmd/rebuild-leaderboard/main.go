@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/ayush/ORBIT/internal/cache"
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/config"
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+)
+
+// rebuild-leaderboard recreates the Redis leaderboards from the ratings in
+// Postgres, e.g. after a Redis flush or when the sets have drifted
+func main() {
+	weeks := flag.Int("weeks", 8, "number of past weekly boards to rebuild, including the current week")
+	flag.Parse()
+
+	// Initialize logger
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg := config.Load()
+
+	// Institution timezone and academic week used for every day and week boundary
+	cal, err := calendar.New(cfg.Timezone, cfg.WeekStartDay, cfg.AcademicYearStart, cfg.ProgrammeYears)
+	if err != nil {
+		logger.Fatal("invalid calendar configuration",
+			zap.Error(err),
+		)
+	}
+
+	// Initialize database connection
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		logger.Fatal("failed to connect to database",
+			zap.Error(err),
+		)
+	}
+
+	redisCache := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	defer redisCache.Close()
+
+	studentDB := database.NewStudentDB(db)
+	studentService := service.NewStudentService(studentDB.StudentRepository(), cal, logger)
+	store := service.NewLeaderboardStore(redisCache, studentService, logger)
+
+	if err := store.Rebuild(context.Background(), *weeks); err != nil {
+		logger.Fatal("failed to rebuild leaderboards",
+			zap.Error(err),
+		)
+	}
+}
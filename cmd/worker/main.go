@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ayush/ORBIT/internal/cache"
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/config"
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/jobs"
+	"github.com/ayush/ORBIT/internal/leetcode"
+	"github.com/ayush/ORBIT/internal/queue"
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/ayush/ORBIT/internal/worker"
+	"go.uber.org/zap"
+)
+
+// The worker runs the refresh scheduler and the job queue consumers without
+// serving HTTP, so it can be scaled and deployed separately from cmd/api
+func main() {
+	// Initialize logger
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg := config.Load()
+
+	// Institution timezone and academic week used for every day and week boundary
+	cal, err := calendar.New(cfg.Timezone, cfg.WeekStartDay, cfg.AcademicYearStart, cfg.ProgrammeYears)
+	if err != nil {
+		logger.Fatal("invalid calendar configuration",
+			zap.Error(err),
+		)
+	}
+
+	// Initialize database connection
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		logger.Fatal("failed to connect to database",
+			zap.Error(err),
+		)
+	}
+
+	studentDB := database.NewStudentDB(db)
+	studentService := service.NewStudentService(studentDB.StudentRepository(), cal, logger)
+
+	// Keep the Redis leaderboards in step with every sync the worker runs;
+	// the API streams the resulting change events to clients
+	redisCache := cache.NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	leaderboardStore := service.NewLeaderboardStore(redisCache, studentService, logger)
+	service.TrackLeaderboards(studentService, leaderboardStore, logger)
+
+	// Weekly progress is recomputed from snapshots as ratings come in and
+	// closed out at each week boundary
+	weeklyStatsService := service.NewWeeklyStatsService(studentService, logger)
+	service.TrackWeeklyStats(studentService, weeklyStatsService, logger)
+	weeklyStatsWorker := worker.NewWeeklyStatsWorker(weeklyStatsService, cal, logger)
+	streakService := service.NewStreakService(studentService, logger)
+	service.TrackStreaks(studentService, streakService, logger)
+	streakWorker := worker.NewStreakWorker(streakService, cal, logger)
+
+	// Header aliases for student imports, optionally extended from a file
+	aliases, err := service.LoadColumnAliases(cfg.ImportAliasesFile)
+	if err != nil {
+		logger.Fatal("failed to load import column aliases",
+			zap.Error(err),
+		)
+	}
+
+	// LeetCode ID checks for uploads that ask for verification
+	verifier := service.NewLeetCodeVerifier(leetcode.NewService(), service.VerifierConfig{
+		RequestsPerMinute: cfg.VerifyRequestsPerMinute,
+		CacheTTL:          cfg.VerifyCacheTTL,
+		CacheSize:         cfg.VerifyCacheSize,
+	})
+	importService := service.NewImportService(studentService, aliases, verifier, logger)
+
+	// Stats refresh scheduler
+	refreshConfig := jobs.DefaultRefreshQueueConfig()
+	refreshConfig.ActiveInterval = cfg.RefreshActiveInterval
+	refreshConfig.DormantInterval = cfg.RefreshDormantInterval
+	refreshConfig.ActiveWindow = cfg.RefreshActiveWindow
+	refreshConfig.BudgetPerHour = cfg.RefreshBudgetPerHour
+	refreshQueue := jobs.NewRefreshQueue(
+		studentDB.StudentRepository(),
+		studentService,
+		logger,
+		refreshConfig,
+	)
+
+	// Batch, department and system stats, recomputed after syncs
+	aggregatorConfig := jobs.DefaultStatsAggregatorConfig()
+	aggregatorConfig.Delay = cfg.StatsAggregateDelay
+	aggregatorConfig.Interval = cfg.StatsAggregateInterval
+	statsAggregator := jobs.NewStatsAggregator(
+		service.NewAnalyticsService(studentService, logger),
+		logger,
+		aggregatorConfig,
+	)
+	refreshQueue.OnRefreshed(func(uint) { statsAggregator.Request() })
+
+	// At-risk flags for students without recent activity
+	detectorConfig := jobs.DefaultInactivityDetectorConfig()
+	detectorConfig.Window = cfg.InactivityWindow
+	detectorConfig.Interval = cfg.InactivityCheckInterval
+	inactivityDetector := jobs.NewInactivityDetector(
+		service.NewRiskService(studentService, logger),
+		logger,
+		detectorConfig,
+	)
+
+	// Job queue consumers; starting the queue also recovers jobs left
+	// running by a previous crash
+	queueConfig := queue.DefaultConfig()
+	queueConfig.Workers = cfg.QueueWorkers
+	jobQueue := queue.New(database.NewJobDB(db), logger, queueConfig)
+	jobs.RegisterQueueHandlers(jobQueue, studentService, importService, refreshQueue, statsAggregator, weeklyStatsService, logger)
+
+	statsAggregator.Start()
+	weeklyStatsWorker.Start()
+	streakWorker.Start()
+	inactivityDetector.Start()
+	refreshQueue.Start()
+	jobQueue.Start()
+
+	logger.Info("worker started",
+		zap.Int("queue_workers", queueConfig.Workers),
+		zap.String("timezone", cal.Timezone()),
+		zap.Stringer("week_start", cal.WeekStartDay()),
+	)
+
+	// Wait for interrupt signal to gracefully shutdown the worker
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("shutting down worker...")
+
+	jobQueue.Stop()
+	refreshQueue.Stop()
+	inactivityDetector.Stop()
+	streakWorker.Stop()
+	weeklyStatsWorker.Stop()
+	statsAggregator.Stop()
+
+	redisCache.Close()
+
+	logger.Info("worker exited properly")
+}
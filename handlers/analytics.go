@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultHistoryDays is how far back aggregate history goes unless ?days= is given
+const defaultHistoryDays = 90
+
+// AnalyticsHandler serves aggregated batch and department statistics, metric
+// distributions and the students flagged as at risk
+type AnalyticsHandler struct {
+	analytics    *service.AnalyticsService
+	risks        *service.RiskService
+	distribution *service.DistributionService
+	logger       *zap.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analytics *service.AnalyticsService, risks *service.RiskService, distribution *service.DistributionService, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analytics:    analytics,
+		risks:        risks,
+		distribution: distribution,
+		logger:       logger,
+	}
+}
+
+// GetDepartmentStats returns the latest stats of a department and their history
+func (h *AnalyticsHandler) GetDepartmentStats(c *gin.Context) {
+	start := time.Now()
+	department := c.Param("dept")
+	logger := h.logger.With(
+		zap.String("handler", "GetDepartmentStats"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("department", department),
+	)
+
+	since, ok := historySince(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.analytics.GetDepartmentAnalytics(c.Request.Context(), department, since)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no stats for department"})
+			return
+		}
+		logger.Error("Failed to get department stats",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get department stats: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Department stats retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.Int("history_count", len(stats.History)),
+	)
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetBatchStats returns the latest stats of a batch and their history
+func (h *AnalyticsHandler) GetBatchStats(c *gin.Context) {
+	start := time.Now()
+	batch := c.Param("batch")
+	logger := h.logger.With(
+		zap.String("handler", "GetBatchStats"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("batch", batch),
+	)
+
+	since, ok := historySince(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.analytics.GetBatchAnalytics(c.Request.Context(), batch, since)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no stats for batch"})
+			return
+		}
+		logger.Error("Failed to get batch stats",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get batch stats: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Batch stats retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.Int("history_count", len(stats.History)),
+	)
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAtRiskStudents lists the students currently flagged by the inactivity
+// detector with their reasons, optionally filtered by department and batch
+func (h *AnalyticsHandler) GetAtRiskStudents(c *gin.Context) {
+	start := time.Now()
+	department := c.Query("department")
+	batch := c.Query("batch")
+	logger := h.logger.With(
+		zap.String("handler", "GetAtRiskStudents"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("department", department),
+		zap.String("batch", batch),
+	)
+
+	students, err := h.risks.ListAtRisk(c.Request.Context(), department, batch)
+	if err != nil {
+		logger.Error("Failed to get at-risk students",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get at-risk students: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("At-risk students retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.Int("count", len(students)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    len(students),
+		"students": students,
+	})
+}
+
+// GetDistribution returns a histogram of students' latest ?metric= (rating,
+// problems_solved or contest_rating) with buckets ?bucket= wide, optionally
+// filtered by department and batch
+func (h *AnalyticsHandler) GetDistribution(c *gin.Context) {
+	start := time.Now()
+	department := c.Query("department")
+	batch := c.Query("batch")
+	logger := h.logger.With(
+		zap.String("handler", "GetDistribution"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("department", department),
+		zap.String("batch", batch),
+	)
+
+	metric, err := service.ParseDistributionMetric(c.Query("metric"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var bucket float64
+	if value := c.Query("bucket"); value != "" {
+		bucket, err = strconv.ParseFloat(value, 64)
+		if err != nil || bucket <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be a positive number"})
+			return
+		}
+	}
+
+	distribution, err := h.distribution.GetDistribution(c.Request.Context(), metric, bucket, department, batch)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBucketSize) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to get distribution",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get distribution: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Distribution retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.String("metric", metric),
+		zap.Int("buckets", len(distribution.Buckets)),
+	)
+
+	c.JSON(http.StatusOK, distribution)
+}
+
+// historySince reads the ?days= window for history, writing a 400 when it is invalid
+func historySince(c *gin.Context) (time.Time, bool) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultHistoryDays)))
+	if err != nil || days < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -days), true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CohortHandler serves cohort comparisons across passing years and batches
+type CohortHandler struct {
+	cohorts *service.CohortService
+	logger  *zap.Logger
+}
+
+// NewCohortHandler creates a new cohort handler
+func NewCohortHandler(cohorts *service.CohortService, logger *zap.Logger) *CohortHandler {
+	return &CohortHandler{
+		cohorts: cohorts,
+		logger:  logger,
+	}
+}
+
+// CompareCohorts compares cohorts week by week since each student's first
+// sync. ?group_by= is passing_year (default) or batch, ?cohorts= is a comma
+// separated list limiting the cohorts, ?weeks= defaults to 26.
+func (h *CohortHandler) CompareCohorts(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "CompareCohorts"),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+
+	groupBy, err := service.ParseCohortGrouping(c.Query("group_by"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	weeks, err := service.ParseCohortWeeks(c.Query("weeks"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var cohorts []string
+	for _, cohort := range strings.Split(c.Query("cohorts"), ",") {
+		if cohort = strings.TrimSpace(cohort); cohort != "" {
+			cohorts = append(cohorts, cohort)
+		}
+	}
+
+	analytics, err := h.cohorts.Compare(c.Request.Context(), service.CohortRequest{
+		GroupBy:    groupBy,
+		Cohorts:    cohorts,
+		Department: c.Query("department"),
+		Weeks:      weeks,
+	})
+	if err != nil {
+		logger.Error("Failed to compare cohorts",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to compare cohorts: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Cohorts compared successfully",
+		zap.Duration("duration", duration),
+		zap.String("group_by", groupBy),
+		zap.Int("cohorts", len(analytics.Cohorts)),
+	)
+
+	c.JSON(http.StatusOK, analytics)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultCompareDays is how many days of history comparisons cover unless
+// ?days= is given
+const defaultCompareDays = 90
+
+// ComparisonHandler serves head-to-head student comparisons
+type ComparisonHandler struct {
+	comparisons *service.ComparisonService
+	logger      *zap.Logger
+}
+
+// NewComparisonHandler creates a new comparison handler
+func NewComparisonHandler(comparisons *service.ComparisonService, logger *zap.Logger) *ComparisonHandler {
+	return &ComparisonHandler{
+		comparisons: comparisons,
+		logger:      logger,
+	}
+}
+
+// CompareStudents compares the students in ?ids=, a comma separated list of
+// IDs, with daily rating and solved series over the last ?days= days
+func (h *ComparisonHandler) CompareStudents(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "CompareStudents"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("ids", c.Query("ids")),
+	)
+
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, value := range strings.Split(c.Query("ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid student ID %q", value)})
+			return
+		}
+		if seen[uint(id)] {
+			continue
+		}
+		seen[uint(id)] = true
+		ids = append(ids, uint(id))
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultCompareDays)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be an integer"})
+		return
+	}
+
+	comparison, err := h.comparisons.Compare(c.Request.Context(), ids, days)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidComparison):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			logger.Error("Failed to compare students",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to compare students: %v", err)})
+		}
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Students compared successfully",
+		zap.Duration("duration", duration),
+		zap.Int("students", len(comparison.Students)),
+		zap.Int("shared_contests", len(comparison.SharedContests)),
+	)
+
+	c.JSON(http.StatusOK, comparison)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Export formats and their content types
+var exportFormats = map[string]string{
+	"csv":  "text/csv",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportHandler serves roster exports
+type ExportHandler struct {
+	exports *service.ExportService
+	logger  *zap.Logger
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exports *service.ExportService, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{
+		exports: exports,
+		logger:  logger,
+	}
+}
+
+// ExportStudents downloads students with their stats as CSV or XLSX, using the
+// same department and batch filters as the student list
+func (h *ExportHandler) ExportStudents(c *gin.Context) {
+	start := time.Now()
+	format := c.DefaultQuery("format", "csv")
+	filter := service.ExportFilter{
+		Department: c.Query("department"),
+		Batch:      c.Query("batch"),
+	}
+
+	logger := h.logger.With(
+		zap.String("handler", "ExportStudents"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("format", format),
+		zap.String("department", filter.Department),
+		zap.String("batch", filter.Batch),
+	)
+
+	contentType, ok := exportFormats[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	var write func(context.Context, io.Writer, service.ExportFilter) error
+	switch format {
+	case "xlsx":
+		write = h.exports.WriteXLSX
+	default:
+		write = h.exports.WriteCSV
+	}
+
+	// Large rosters take longer to stream than the server's write timeout
+	// allows for ordinary requests
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="students_%s.%s"`, time.Now().Format("20060102"), format))
+
+	if err := write(c.Request.Context(), c.Writer, filter); err != nil {
+		logger.Error("Failed to export students",
+			zap.Error(err),
+		)
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to export students: %v", err)})
+		}
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Students exported successfully",
+		zap.Duration("duration", duration),
+	)
+}
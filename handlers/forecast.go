@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultForecastDays is how far ahead forecasts look unless ?date= is given
+const defaultForecastDays = 90
+
+// ForecastHandler serves rating and problem count forecasts
+type ForecastHandler struct {
+	forecasts *service.ForecastService
+	calendar  *calendar.Calendar
+	logger    *zap.Logger
+}
+
+// NewForecastHandler creates a new forecast handler
+func NewForecastHandler(forecasts *service.ForecastService, cal *calendar.Calendar, logger *zap.Logger) *ForecastHandler {
+	return &ForecastHandler{
+		forecasts: forecasts,
+		calendar:  cal,
+		logger:    logger,
+	}
+}
+
+// GetStudentForecast predicts a student's rating and problem count at
+// ?date= (default 90 days ahead) using the ?model= trend (default linear)
+func (h *ForecastHandler) GetStudentForecast(c *gin.Context) {
+	start := time.Now()
+	studentID := c.Param("id")
+	logger := h.logger.With(
+		zap.String("handler", "GetStudentForecast"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("student_id", studentID),
+	)
+
+	id, err := strconv.ParseUint(studentID, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+	model, err := service.ParseForecastModel(c.Query("model"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, ok := h.forecastDate(c)
+	if !ok {
+		return
+	}
+
+	forecast, err := h.forecasts.ForecastStudent(c.Request.Context(), uint(id), date, model)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		case errors.Is(err, service.ErrInvalidForecastDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInsufficientHistory):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			logger.Error("Failed to forecast student",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to forecast student: %v", err)})
+		}
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Student forecast generated successfully",
+		zap.Duration("duration", duration),
+		zap.String("model", model),
+		zap.Int("snapshots", forecast.Snapshots),
+	)
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// GetBatchForecast counts the students of a batch projected to reach
+// ?threshold= on ?metric= (rating or problems_solved) by ?date=
+func (h *ForecastHandler) GetBatchForecast(c *gin.Context) {
+	start := time.Now()
+	batch := c.Param("batch")
+	logger := h.logger.With(
+		zap.String("handler", "GetBatchForecast"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("batch", batch),
+	)
+
+	threshold, err := strconv.ParseFloat(c.Query("threshold"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold is required and must be a number"})
+		return
+	}
+	metric, err := service.ParseForecastMetric(c.Query("metric"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	model, err := service.ParseForecastModel(c.Query("model"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, ok := h.forecastDate(c)
+	if !ok {
+		return
+	}
+
+	forecast, err := h.forecasts.ForecastBatch(c.Request.Context(), batch, metric, threshold, date, model)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no students in batch"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidForecastDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to forecast batch",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to forecast batch: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Batch forecast generated successfully",
+		zap.Duration("duration", duration),
+		zap.String("metric", metric),
+		zap.Float64("threshold", threshold),
+		zap.Int("projected_to_cross", forecast.ProjectedToCross),
+	)
+
+	c.JSON(http.StatusOK, forecast)
+}
+
+// forecastDate reads ?date=, defaulting to 90 days ahead, writing a 400 when
+// it is not a valid date
+func (h *ForecastHandler) forecastDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("date")
+	if value == "" {
+		return h.calendar.StartOfDay(h.calendar.Now()).AddDate(0, 0, defaultForecastDays), true
+	}
+	date, err := h.calendar.ParseDate(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return time.Time{}, false
+	}
+	return date, true
+}
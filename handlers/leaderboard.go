@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Live leaderboard stream settings
+const (
+	// leaderboardStreamHeartbeat keeps idle connections alive through proxies
+	leaderboardStreamHeartbeat = 15 * time.Second
+	// leaderboardStreamDebounce folds a burst of syncs into one update
+	leaderboardStreamDebounce = time.Second
+	// leaderboardStreamRetry is how long clients wait before reconnecting
+	leaderboardStreamRetry = 3 * time.Second
+)
+
+// LeaderboardHandler serves student rankings
+type LeaderboardHandler struct {
+	leaderboards *service.LeaderboardService
+	store        *service.LeaderboardStore
+	stream       *service.LeaderboardStream
+	calendar     *calendar.Calendar
+	logger       *zap.Logger
+}
+
+// NewLeaderboardHandler creates a new leaderboard handler
+func NewLeaderboardHandler(leaderboards *service.LeaderboardService, store *service.LeaderboardStore, stream *service.LeaderboardStream, cal *calendar.Calendar, logger *zap.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboards: leaderboards,
+		store:        store,
+		stream:       stream,
+		calendar:     cal,
+		logger:       logger,
+	}
+}
+
+// GetLeaderboard ranks students by ?metric= over ?period=, optionally within a
+// department or batch
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	start := time.Now()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	logger := h.logger.With(
+		zap.String("handler", "GetLeaderboard"),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+
+	req, ok := leaderboardRequest(c)
+	if !ok {
+		return
+	}
+	req.Page = page
+	req.PageSize = pageSize
+
+	leaderboard, err := h.leaderboards.GetLeaderboard(c.Request.Context(), req)
+	if err != nil {
+		logger.Error("Failed to get leaderboard",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get leaderboard: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Leaderboard retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.String("metric", req.Metric),
+		zap.String("period", req.Period),
+		zap.Int("entry_count", len(leaderboard.Entries)),
+	)
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+// GetTrendingStudents returns the students with the largest rating gain over
+// the last ?days=
+func (h *LeaderboardHandler) GetTrendingStudents(c *gin.Context) {
+	start := time.Now()
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	logger := h.logger.With(
+		zap.String("handler", "GetTrendingStudents"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.Int("days", days),
+		zap.Int("limit", limit),
+	)
+
+	trending, err := h.leaderboards.GetTrendingStudents(c.Request.Context(), days, limit)
+	if err != nil {
+		logger.Error("Failed to get trending students",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get trending students: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Trending students retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.Int("entry_count", len(trending)),
+	)
+
+	c.JSON(http.StatusOK, trending)
+}
+
+// GetTopStudents returns the top of a Redis leaderboard: the global board by
+// default, or one ?department=, ?batch= or ?week= board
+func (h *LeaderboardHandler) GetTopStudents(c *gin.Context) {
+	start := time.Now()
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	logger := h.logger.With(
+		zap.String("handler", "GetTopStudents"),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+
+	scope, ok := h.leaderboardScope(c)
+	if !ok {
+		return
+	}
+
+	top, total, err := h.store.Top(c.Request.Context(), scope, offset, limit)
+	if err != nil {
+		logger.Error("Failed to read leaderboard",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read leaderboard: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Top students retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.String("board", scope.Key()),
+		zap.Int("entry_count", len(top)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+		"entries": top,
+	})
+}
+
+// GetStudentRank returns a student's position on a Redis leaderboard
+func (h *LeaderboardHandler) GetStudentRank(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "GetStudentRank"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("student_id", c.Param("id")),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logger.Warn("Invalid student ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	scope, ok := h.leaderboardScope(c)
+	if !ok {
+		return
+	}
+
+	rank, err := h.store.Rank(c.Request.Context(), scope, uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student is not on this leaderboard"})
+			return
+		}
+		logger.Error("Failed to read student rank",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read student rank: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Student rank retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.Int64("rank", rank.Rank),
+	)
+
+	c.JSON(http.StatusOK, rank)
+}
+
+// StreamLeaderboard pushes the top of a Redis leaderboard over Server-Sent
+// Events, taking the same scope filters as GetTopStudents. Each "leaderboard"
+// event carries the whole top ?limit=, so a reconnecting client only needs the
+// first event after it reconnects to catch up.
+func (h *LeaderboardHandler) StreamLeaderboard(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	logger := h.logger.With(
+		zap.String("handler", "StreamLeaderboard"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("last_event_id", c.GetHeader("Last-Event-ID")),
+	)
+
+	scope, ok := h.leaderboardScope(c)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe := h.stream.Subscribe(scope)
+	defer unsubscribe()
+
+	// The server's write timeout is meant for ordinary requests; a stream
+	// stays open until the client goes away
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func() {
+		top, total, err := h.store.Top(c.Request.Context(), scope, 0, limit)
+		if err != nil {
+			logger.Error("Failed to read leaderboard",
+				zap.Error(err),
+			)
+			c.Render(-1, sse.Event{
+				Event: "error",
+				Data:  gin.H{"error": fmt.Sprintf("failed to read leaderboard: %v", err)},
+			})
+			return
+		}
+		c.Render(-1, sse.Event{
+			Id:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+			Event: "leaderboard",
+			Retry: uint(leaderboardStreamRetry.Milliseconds()),
+			Data: gin.H{
+				"board":   scope.Key(),
+				"total":   total,
+				"entries": top,
+			},
+		})
+	}
+
+	logger.Info("Leaderboard stream opened",
+		zap.String("board", scope.Key()),
+	)
+	send()
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(leaderboardStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	var pending <-chan time.Time
+	updates := 0
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case _, ok := <-events:
+			if !ok {
+				return false
+			}
+			if pending == nil {
+				pending = time.After(leaderboardStreamDebounce)
+			}
+		case <-pending:
+			pending = nil
+			updates++
+			send()
+		case now := <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": now})
+		}
+		return true
+	})
+
+	logger.Info("Leaderboard stream closed",
+		zap.String("board", scope.Key()),
+		zap.Int("updates", updates),
+	)
+}
+
+// leaderboardScope reads which Redis board a request is for, writing a 400
+// when more than one is named or the week is malformed
+func (h *LeaderboardHandler) leaderboardScope(c *gin.Context) (service.LeaderboardScope, bool) {
+	scope := service.LeaderboardScope{
+		Department: c.Query("department"),
+		Batch:      c.Query("batch"),
+	}
+	if value := c.Query("week"); value != "" {
+		week, err := h.calendar.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "week must be a date in YYYY-MM-DD format"})
+			return scope, false
+		}
+		scope.Week = h.calendar.WeekStart(week)
+	}
+
+	named := 0
+	for _, set := range []bool{scope.Department != "", scope.Batch != "", !scope.Week.IsZero()} {
+		if set {
+			named++
+		}
+	}
+	if named > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "choose at most one of department, batch or week"})
+		return scope, false
+	}
+	return scope, true
+}
+
+// leaderboardRequest reads the metric, period and scope filters shared by the
+// leaderboard endpoints, writing a 400 when they are invalid
+func leaderboardRequest(c *gin.Context) (service.LeaderboardRequest, bool) {
+	metric, err := service.ParseLeaderboardMetric(c.Query("metric"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return service.LeaderboardRequest{}, false
+	}
+	period, err := service.ParseLeaderboardPeriod(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return service.LeaderboardRequest{}, false
+	}
+
+	return service.LeaderboardRequest{
+		Metric:     metric,
+		Period:     period,
+		Department: c.Query("department"),
+		Batch:      c.Query("batch"),
+	}, true
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReportHandler serves weekly batch and department reports
+type ReportHandler struct {
+	reports  *service.ReportService
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reports *service.ReportService, cal *calendar.Calendar, logger *zap.Logger) *ReportHandler {
+	return &ReportHandler{
+		reports:  reports,
+		calendar: cal,
+		logger:   logger,
+	}
+}
+
+// WeeklyReport renders the weekly HTML report for a batch or department.
+// The week defaults to the current one; store=true keeps the report so it can
+// be fetched again from /reports/:id.
+func (h *ReportHandler) WeeklyReport(c *gin.Context) {
+	start := time.Now()
+	filter := service.ReportFilter{
+		Batch:      c.Query("batch"),
+		Department: c.Query("department"),
+	}
+	store, _ := strconv.ParseBool(c.Query("store"))
+
+	logger := h.logger.With(
+		zap.String("handler", "WeeklyReport"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("batch", filter.Batch),
+		zap.String("department", filter.Department),
+	)
+
+	if filter.Batch == "" && filter.Department == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batch or department is required"})
+		return
+	}
+
+	week := time.Now()
+	if value := c.Query("week"); value != "" {
+		parsed, err := h.calendar.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "week must be a date in YYYY-MM-DD format"})
+			return
+		}
+		week = parsed
+	}
+
+	report, err := h.reports.GenerateWeekly(c.Request.Context(), filter, week, store)
+	if err != nil {
+		logger.Error("Failed to generate weekly report",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate weekly report: %v", err)})
+		return
+	}
+
+	if report.ID != 0 {
+		c.Header("X-Report-ID", strconv.FormatUint(uint64(report.ID), 10))
+	}
+
+	duration := time.Since(start)
+	logger.Info("Weekly report generated successfully",
+		zap.Duration("duration", duration),
+		zap.Time("week_start", report.WeekStart),
+		zap.Bool("stored", store),
+	)
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(report.HTML))
+}
+
+// ListReports returns stored reports, optionally filtered by batch and department
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	start := time.Now()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	filter := service.ReportFilter{
+		Batch:      c.Query("batch"),
+		Department: c.Query("department"),
+	}
+
+	logger := h.logger.With(
+		zap.String("handler", "ListReports"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.Int("page", page),
+		zap.Int("page_size", pageSize),
+	)
+
+	reports, err := h.reports.ListReports(c.Request.Context(), page, pageSize, filter)
+	if err != nil {
+		logger.Error("Failed to list reports",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list reports: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Reports listed successfully",
+		zap.Duration("duration", duration),
+		zap.Int("report_count", len(reports)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"reports":   reports,
+	})
+}
+
+// GetReport returns a stored report as HTML
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "GetReport"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("report_id", c.Param("id")),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logger.Warn("Invalid report ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report ID"})
+		return
+	}
+
+	report, err := h.reports.GetReport(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+			return
+		}
+		logger.Error("Failed to get report",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get report: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Report retrieved successfully",
+		zap.Duration("duration", duration),
+	)
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(report.HTML))
+}
@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,19 +13,31 @@ import (
 	"time"
 
 	"github.com/ayush/ORBIT/internal/cache"
-	"github.com/ayush/ORBIT/internal/leetcode"
+	"github.com/ayush/ORBIT/internal/jobs"
 	"github.com/ayush/ORBIT/internal/models"
 	"github.com/ayush/ORBIT/internal/service"
 	"github.com/gin-gonic/gin"
-	"github.com/xuri/excelize/v2"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	service *service.StudentService
-	cache   *cache.RedisCache
-	logger  *zap.Logger
+	service   *service.StudentService
+	imports   *service.ImportService
+	cache     *cache.RedisCache
+	refresher RefreshRequester
+	jobs      JobEnqueuer
+	logger    *zap.Logger
+}
+
+// JobEnqueuer adds work to the persistent job queue
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error)
+}
+
+// RefreshRequester asks for a student's LeetCode stats to be refreshed ahead of schedule
+type RefreshRequester interface {
+	RequestRefresh(studentID uint)
 }
 
 type Database interface {
@@ -50,11 +62,14 @@ const (
 	maxFileSize = 10 << 20 // 10MB
 )
 
-func NewHandler(service *service.StudentService, cache *cache.RedisCache, logger *zap.Logger) *Handler {
+func NewHandler(service *service.StudentService, imports *service.ImportService, cache *cache.RedisCache, refresher RefreshRequester, jobs JobEnqueuer, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: service,
-		cache:   cache,
-		logger:  logger,
+		service:   service,
+		imports:   imports,
+		cache:     cache,
+		refresher: refresher,
+		jobs:      jobs,
+		logger:    logger,
 	}
 }
 
@@ -297,55 +312,6 @@ func (h *Handler) UpdateContestHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, history)
 }
 
-// GetLeaderboard retrieves the student leaderboard
-func (h *Handler) GetLeaderboard(c *gin.Context) {
-	timeframe := c.DefaultQuery("timeframe", "all")
-	department := c.Query("department")
-	batch := c.Query("batch")
-
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("leaderboard:%s:dept:%s:batch:%s", timeframe, department, batch)
-	if cached, err := h.cache.Get(c, cacheKey); err == nil {
-		c.JSON(http.StatusOK, cached)
-		return
-	}
-
-	leaderboard, err := h.service.GetLeaderboard(c.Request.Context(), timeframe, department, batch)
-	if err != nil {
-		h.logger.Error("failed to get leaderboard", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get leaderboard"})
-		return
-	}
-
-	// Cache the result for 1 hour
-	h.cache.Set(c, cacheKey, leaderboard, time.Hour)
-	c.JSON(http.StatusOK, leaderboard)
-}
-
-// GetTrendingStudents retrieves trending students based on recent activity
-func (h *Handler) GetTrendingStudents(c *gin.Context) {
-	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("trending:days:%d:limit:%d", days, limit)
-	if cached, err := h.cache.Get(c, cacheKey); err == nil {
-		c.JSON(http.StatusOK, cached)
-		return
-	}
-
-	trending, err := h.service.GetTrendingStudents(c.Request.Context(), days, limit)
-	if err != nil {
-		h.logger.Error("failed to get trending students", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get trending students"})
-		return
-	}
-
-	// Cache the result for 1 hour
-	h.cache.Set(c, cacheKey, trending, time.Hour)
-	c.JSON(http.StatusOK, trending)
-}
-
 // CreateStudent creates a new student record
 func (h *Handler) CreateStudent(c *gin.Context) {
 	start := time.Now()
@@ -507,6 +473,9 @@ func (h *Handler) GetStudentDetails(c *gin.Context) {
 		return
 	}
 
+	// Someone is looking at this profile, so bump it in the refresh queue
+	h.refresher.RequestRefresh(uint(id))
+
 	// Get LeetCode stats
 	leetcodeStats, err := h.service.GetLeetCodeStats(c.Request.Context(), uint(id))
 	if err == nil && leetcodeStats != nil {
@@ -528,7 +497,34 @@ func (h *Handler) GetStudentDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, student)
 }
 
-// BulkCreateStudents handles bulk student creation from file upload
+// uploadFormats lists the file extensions accepted by BulkCreateStudents
+var uploadFormats = map[string]bool{
+	".xlsx":   true,
+	".csv":    true,
+	".json":   true,
+	".ndjson": true,
+	".jsonl":  true,
+}
+
+// bodyFormats maps the content types accepted as a raw request body to the
+// extension the body is stored under
+var bodyFormats = map[string]string{
+	"application/json":     ".json",
+	"application/x-ndjson": ".ndjson",
+	"application/jsonl":    ".ndjson",
+}
+
+// incomingUpload is a roster received either as a multipart file or as a raw
+// JSON or NDJSON request body
+type incomingUpload struct {
+	name    string
+	ext     string
+	mapping string
+	save    func(path string) (int64, error)
+}
+
+// BulkCreateStudents handles bulk student creation from a file upload or a
+// JSON/NDJSON request body
 func (h *Handler) BulkCreateStudents(c *gin.Context) {
 	start := time.Now()
 	logger := h.logger.With(
@@ -538,34 +534,54 @@ func (h *Handler) BulkCreateStudents(c *gin.Context) {
 
 	logger.Info("Starting bulk student creation")
 
-	file, err := c.FormFile("file")
-	if err != nil {
-		logger.Error("No file uploaded",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+	upload, ok := h.receiveUpload(c, logger)
+	if !ok {
 		return
 	}
 
 	logger = logger.With(
-		zap.String("filename", file.Filename),
-		zap.Int64("file_size", file.Size),
+		zap.String("filename", upload.name),
 	)
 
-	if file.Size > maxFileSize {
-		logger.Warn("File too large",
-			zap.Int64("max_size", maxFileSize),
+	// Optional JSON mapping of student fields to the file's column headers
+	var columnMapping *string
+	var parsedMapping *service.ColumnMapping
+	if upload.mapping != "" {
+		var err error
+		parsedMapping, err = service.ParseColumnMapping(upload.mapping)
+		if err != nil {
+			logger.Warn("Invalid column mapping",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		columnMapping = &upload.mapping
+	}
+
+	mode, err := service.ParseImportMode(c.Query("mode"))
+	if err != nil {
+		logger.Warn("Invalid import mode",
+			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext != ".xlsx" && ext != ".csv" {
-		logger.Warn("Invalid file format",
-			zap.String("extension", ext),
+	verify, err := service.ParseVerifyPolicy(c.Query("verify"))
+	if err != nil {
+		logger.Warn("Invalid verify policy",
+			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file format. Only .xlsx and .csv files are allowed"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger = logger.With(
+		zap.String("mode", mode),
+		zap.String("verify", verify),
+	)
+
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		h.validateUpload(c, logger, upload, parsedMapping, mode, verify)
 		return
 	}
 
@@ -579,26 +595,27 @@ func (h *Handler) BulkCreateStudents(c *gin.Context) {
 	}
 
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s%s", timestamp, strings.TrimSuffix(file.Filename, ext), ext)
+	filename := fmt.Sprintf("%s_%s%s", timestamp, strings.TrimSuffix(upload.name, filepath.Ext(upload.name)), upload.ext)
 	filepath := filepath.Join(uploadDir, filename)
 
-	if err := c.SaveUploadedFile(file, filepath); err != nil {
-		logger.Error("Failed to save file",
-			zap.Error(err),
-			zap.String("filepath", filepath),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	size, err := upload.save(filepath)
+	if err != nil {
+		os.Remove(filepath)
+		h.uploadSaveFailed(c, logger, err, filepath)
 		return
 	}
 
 	fileUpload := &models.FileUpload{
-		FileName:     filename,
-		OriginalName: file.Filename,
-		FileType:     ext[1:],
-		FileSize:     file.Size,
-		StoragePath:  filepath,
-		Status:       "processing",
-		UploadedBy:   nil,
+		FileName:      filename,
+		OriginalName:  upload.name,
+		FileType:      upload.ext[1:],
+		FileSize:      size,
+		StoragePath:   filepath,
+		Status:        service.UploadStatusPending,
+		UploadedBy:    nil,
+		ColumnMapping: columnMapping,
+		ImportMode:    mode,
+		VerifyPolicy:  verify,
 	}
 
 	if err := h.service.CreateFileUpload(fileUpload); err != nil {
@@ -609,296 +626,162 @@ func (h *Handler) BulkCreateStudents(c *gin.Context) {
 		return
 	}
 
+	job, err := h.jobs.Enqueue(c.Request.Context(), jobs.TypeImportStudents, jobs.ImportStudentsPayload{FileUploadID: fileUpload.ID})
+	if err != nil {
+		logger.Error("Failed to queue file processing",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue file processing"})
+		return
+	}
+
 	duration := time.Since(start)
-	logger.Info("File upload processed successfully",
+	logger.Info("File upload queued successfully",
 		zap.Duration("duration", duration),
-		zap.String("status", "processing"),
+		zap.String("status", fileUpload.Status),
+		zap.Int64("file_size", size),
 		zap.Uint("file_upload_id", fileUpload.ID),
+		zap.Uint("job_id", job.ID),
 	)
 
-	go h.processUploadedFile(fileUpload)
-
 	c.JSON(http.StatusAccepted, gin.H{
-		"message": "File uploaded successfully. Processing started.",
+		"message": "File uploaded successfully. Processing queued.",
 		"file_id": fileUpload.ID,
+		"job_id":  job.ID,
+		"mode":    mode,
+		"verify":  verify,
 	})
 }
 
-func (h *Handler) processUploadedFile(fileUpload *models.FileUpload) {
-	start := time.Now()
-	logger := h.logger.With(
-		zap.String("handler", "processUploadedFile"),
-		zap.Uint("file_upload_id", fileUpload.ID),
-		zap.String("filename", fileUpload.FileName),
-	)
-
-	logger.Info("Starting file processing")
-
-	var records [][]string
-	var err error
-
-	if strings.HasSuffix(fileUpload.FileName, ".xlsx") {
-		records, err = readExcelFile(fileUpload.StoragePath)
-	} else {
-		records, err = readCSVFile(fileUpload.StoragePath)
+// receiveUpload accepts either a JSON or NDJSON request body or a multipart
+// "file" field, writing the error response itself when neither is usable
+func (h *Handler) receiveUpload(c *gin.Context, logger *zap.Logger) (*incomingUpload, bool) {
+	if ext, ok := bodyFormats[c.ContentType()]; ok {
+		name := c.DefaultQuery("filename", "upload"+ext)
+		return &incomingUpload{
+			name:    name,
+			ext:     ext,
+			mapping: c.Query("mapping"),
+			save: func(path string) (int64, error) {
+				f, err := os.Create(path)
+				if err != nil {
+					return 0, err
+				}
+				defer f.Close()
+				return io.Copy(f, http.MaxBytesReader(c.Writer, c.Request.Body, maxFileSize))
+			},
+		}, true
 	}
 
+	file, err := c.FormFile("file")
 	if err != nil {
-		logger.Error("Failed to read file",
+		logger.Error("No file uploaded",
 			zap.Error(err),
 		)
-		updateFileUploadStatus(h, fileUpload, "failed", err.Error(), 0, 0, 0)
-		return
-	}
-
-	if len(records) < 2 {
-		logger.Warn("Empty file or no data rows")
-		updateFileUploadStatus(h, fileUpload, "failed", "File is empty or has no data rows", 0, 0, 0)
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, false
 	}
 
-	successful := 0
-	failed := 0
-	errors := make([]string, 0)
-
-	logger.Info("Processing records",
-		zap.Int("total_records", len(records)-1), // Subtract header row
-	)
-
-	for i, record := range records[1:] {
-		recordLogger := logger.With(
-			zap.Int("row_number", i+2),
+	if file.Size > maxFileSize {
+		logger.Warn("File too large",
+			zap.String("filename", file.Filename),
+			zap.Int64("file_size", file.Size),
+			zap.Int64("max_size", maxFileSize),
 		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+		return nil, false
+	}
 
-		if len(record) != 5 {
-			failed++
-			msg := fmt.Sprintf("Row %d: Invalid number of columns", i+2)
-			errors = append(errors, msg)
-			recordLogger.Warn("Invalid record format",
-				zap.Int("columns_found", len(record)),
-				zap.Int("columns_expected", 5),
-			)
-			continue
-		}
-
-		student := models.Student{
-			StudentID:   record[0],
-			Name:        record[1],
-			Email:       record[2],
-			LeetcodeID:  record[3],
-			PassingYear: parseInt(record[4]),
-		}
-
-		recordLogger = recordLogger.With(
-			zap.String("student_id", student.StudentID),
-			zap.String("leetcode_id", student.LeetcodeID),
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !uploadFormats[ext] {
+		logger.Warn("Invalid file format",
+			zap.String("extension", ext),
 		)
-
-		if err := h.service.CreateStudent(&student); err != nil {
-			failed++
-			msg := fmt.Sprintf("Row %d: %s", i+2, err.Error())
-			errors = append(errors, msg)
-			recordLogger.Error("Failed to create student",
-				zap.Error(err),
-			)
-			continue
-		}
-
-		successful++
-		recordLogger.Info("Student created successfully")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file format. Only .xlsx, .csv, .json and .ndjson files are allowed"})
+		return nil, false
 	}
 
-	status := "completed"
-	if failed > 0 {
-		status = "completed_with_errors"
+	mapping := c.PostForm("mapping")
+	if mapping == "" {
+		mapping = c.Query("mapping")
 	}
 
-	duration := time.Since(start)
-	logger.Info("File processing completed",
-		zap.Duration("duration", duration),
-		zap.String("status", status),
-		zap.Int("successful", successful),
-		zap.Int("failed", failed),
-		zap.Int("total_processed", len(records)-1),
-	)
-
-	errorDetails := strings.Join(errors, "\n")
-	updateFileUploadStatus(h, fileUpload, status, errorDetails, len(records)-1, successful, failed)
+	return &incomingUpload{
+		name:    file.Filename,
+		ext:     ext,
+		mapping: mapping,
+		save: func(path string) (int64, error) {
+			if err := c.SaveUploadedFile(file, path); err != nil {
+				return 0, err
+			}
+			return file.Size, nil
+		},
+	}, true
 }
 
-func readExcelFile(filepath string) ([][]string, error) {
-	f, err := excelize.OpenFile(filepath)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("no sheets found in excel file")
+// uploadSaveFailed reports a roster that could not be stored, telling an
+// oversized request body apart from a server-side failure
+func (h *Handler) uploadSaveFailed(c *gin.Context, logger *zap.Logger, err error, path string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logger.Warn("File too large",
+			zap.Int64("max_size", maxFileSize),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large"})
+		return
 	}
 
-	return f.GetRows(sheets[0])
+	logger.Error("Failed to save file",
+		zap.Error(err),
+		zap.String("filepath", path),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 }
 
-func readCSVFile(filepath string) ([][]string, error) {
-	f, err := os.Open(filepath)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+// validateUpload runs the import checks against an uploaded file and returns
+// the per-row report without creating any students
+func (h *Handler) validateUpload(c *gin.Context, logger *zap.Logger, upload *incomingUpload, mapping *service.ColumnMapping, mode, verify string) {
+	start := time.Now()
 
-	records, err := csv.NewReader(f).ReadAll()
+	tmp, err := os.CreateTemp("", "orbit-dry-run-*"+upload.ext)
 	if err != nil {
-		return nil, err
-	}
-
-	return records, nil
-}
-
-func updateFileUploadStatus(h *Handler, fileUpload *models.FileUpload, status, errorDetails string, total, successful, failed int) {
-	fileUpload.Status = status
-	fileUpload.TotalRecords = total
-	fileUpload.SuccessfulRecords = successful
-	fileUpload.FailedRecords = failed
-	if errorDetails != "" {
-		fileUpload.ErrorDetails = &errorDetails
+		logger.Error("Failed to create temporary file",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process file"})
+		return
 	}
-	fileUpload.UpdatedAt = time.Now()
+	tmp.Close()
+	defer os.Remove(tmp.Name())
 
-	if err := h.service.UpdateFileUpload(fileUpload); err != nil {
-		log.Printf("Failed to update file upload status: %v", err)
+	if _, err := upload.save(tmp.Name()); err != nil {
+		h.uploadSaveFailed(c, logger, err, tmp.Name())
+		return
 	}
-}
 
-func parseInt(s string) int {
-	i, _ := strconv.Atoi(strings.TrimSpace(s))
-	return i
-}
-
-// UpdateAllContestHistories updates contest histories for all students
-func (h *Handler) UpdateAllContestHistories(c *gin.Context) {
-	start := time.Now()
-	logger := h.logger.With(
-		zap.String("handler", "UpdateAllContestHistories"),
-		zap.String("request_id", c.GetString("request_id")),
-	)
-
-	logger.Info("Starting contest history update for all students")
-
-	// Create LeetCode service
-	leetcodeService := leetcode.NewService()
-
-	// Get all students (paginated)
-	page := 1
-	pageSize := 10
-	var processedCount int
-	var failedCount int
-	var totalProcessed int
-
-	for {
-		logger.Info("Processing student batch",
-			zap.Int("page", page),
-			zap.Int("page_size", pageSize),
-			zap.Int("processed_so_far", totalProcessed),
+	report, err := h.imports.ValidateFile(c.Request.Context(), tmp.Name(), tmp.Name(), mapping, mode, verify)
+	if err != nil {
+		logger.Warn("Dry run could not validate file",
+			zap.Error(err),
 		)
-
-		students, err := h.service.ListStudents(c.Request.Context(), page, pageSize, "", "")
-		if err != nil {
-			logger.Error("Failed to fetch students",
-				zap.Error(err),
-				zap.Int("page", page),
-			)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch students"})
-			return
-		}
-
-		if len(students) == 0 {
-			break // No more students to process
-		}
-
-		// Process each student in the batch
-		for _, student := range students {
-			studentLogger := logger.With(
-				zap.String("student_id", student.StudentID),
-				zap.String("leetcode_id", student.LeetcodeID),
-				zap.Uint("internal_id", student.ID),
-			)
-
-			studentLogger.Info("Processing student")
-
-			// Get fresh contest stats
-			contestStats, err := leetcodeService.GetContestRanking(student.LeetcodeID)
-			if err != nil {
-				studentLogger.Error("Failed to fetch contest stats",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			// Delete existing contest history
-			if err := h.service.DeleteContestHistory(c.Request.Context(), student.ID); err != nil {
-				studentLogger.Error("Failed to delete existing contest history",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			now := time.Now()
-			var histories []models.ContestHistory
-
-			// Create new contest history entries
-			for _, contest := range contestStats.Data.UserContestRankingHistory {
-				history := models.ContestHistory{
-					StudentID:         student.ID,
-					ContestTitle:      contest.Contest.Title,
-					Rating:            contest.Rating,
-					Ranking:           contest.Ranking,
-					ProblemsSolved:    contest.ProblemsSolved,
-					FinishTimeSeconds: int64(contest.FinishTimeInSeconds),
-					ContestDate:       now,
-					CreatedAt:         now,
-				}
-				histories = append(histories, history)
-			}
-
-			// Add new contest histories
-			if err := h.service.AddContestHistories(c.Request.Context(), student.ID, histories); err != nil {
-				studentLogger.Error("Failed to add contest histories",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			studentLogger.Info("Successfully processed student",
-				zap.Int("histories_added", len(histories)),
-			)
-			processedCount++
-			totalProcessed++
-
-			// Add a small delay between students to avoid rate limiting
-			time.Sleep(500 * time.Millisecond)
-		}
-
-		page++
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	duration := time.Since(start)
-	logger.Info("Contest history update completed for all students",
+	logger.Info("Dry run completed",
 		zap.Duration("duration", duration),
-		zap.Int("total_processed", processedCount),
-		zap.Int("failed_count", failedCount),
+		zap.Int("total_rows", report.TotalRows),
+		zap.Int("valid_rows", report.ValidRows),
+		zap.Int("invalid_rows", report.InvalidRows),
 	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "Contest histories update completed",
-		"processed_count": processedCount,
-		"failed_count":    failedCount,
-		"duration":        duration.String(),
-	})
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdateAllContestHistories queues a contest history sync for every student
+func (h *Handler) UpdateAllContestHistories(c *gin.Context) {
+	h.enqueueForAllStudents(c, "UpdateAllContestHistories", jobs.TypeSyncContests)
 }
 
 // UpdateStudentRating updates a student's LeetCode rating
@@ -1042,30 +925,28 @@ func (h *Handler) UpdateStudentRating(c *gin.Context) {
 	c.JSON(http.StatusOK, rating)
 }
 
-// UpdateAllStudentRatings updates LeetCode ratings for all students
+// UpdateAllStudentRatings queues a rating sync for every student
 func (h *Handler) UpdateAllStudentRatings(c *gin.Context) {
+	h.enqueueForAllStudents(c, "UpdateAllStudentRatings", jobs.TypeSyncRating)
+}
+
+// enqueueForAllStudents adds one job of the given type per student with a LeetCode ID
+func (h *Handler) enqueueForAllStudents(c *gin.Context, handler, jobType string) {
 	start := time.Now()
 	logger := h.logger.With(
-		zap.String("handler", "UpdateAllStudentRatings"),
+		zap.String("handler", handler),
 		zap.String("request_id", c.GetString("request_id")),
+		zap.String("job_type", jobType),
 	)
 
-	logger.Info("Starting rating update for all students")
+	logger.Info("Queueing jobs for all students")
 
-	// Get all students (paginated)
 	page := 1
-	pageSize := 10
-	var processedCount int
-	var failedCount int
-	var totalProcessed int
+	pageSize := 100
+	var queuedCount int
+	var skippedCount int
 
 	for {
-		logger.Info("Processing student batch",
-			zap.Int("page", page),
-			zap.Int("page_size", pageSize),
-			zap.Int("processed_so_far", totalProcessed),
-		)
-
 		students, err := h.service.ListStudents(c.Request.Context(), page, pageSize, "", "")
 		if err != nil {
 			logger.Error("Failed to fetch students",
@@ -1080,104 +961,36 @@ func (h *Handler) UpdateAllStudentRatings(c *gin.Context) {
 			break // No more students to process
 		}
 
-		// Process each student in the batch
 		for _, student := range students {
-			studentLogger := logger.With(
-				zap.String("student_id", student.StudentID),
-				zap.String("leetcode_id", student.LeetcodeID),
-				zap.Uint("internal_id", student.ID),
-			)
-
 			if student.LeetcodeID == "" {
-				studentLogger.Warn("Student has no LeetCode ID, skipping")
-				failedCount++
+				skippedCount++
 				continue
 			}
 
-			studentLogger.Info("Processing student")
-
-			// Get fresh LeetCode stats with retries
-			var leetcodeStats *models.LeetCodeStats
-			maxRetries := 3
-			var lastErr error
-			for i := 0; i < maxRetries; i++ {
-				leetcodeStats, lastErr = h.service.GetLeetCodeStats(c.Request.Context(), student.LeetcodeID)
-				if lastErr == nil {
-					break
-				}
-				studentLogger.Warn("Failed to fetch LeetCode stats, retrying",
-					zap.Error(lastErr),
-					zap.Int("attempt", i+1),
-				)
-				time.Sleep(time.Second * time.Duration(i+1)) // Exponential backoff
-			}
-
-			if lastErr != nil {
-				studentLogger.Error("Failed to fetch LeetCode stats after retries",
-					zap.Error(lastErr),
-				)
-				failedCount++
-				continue
-			}
-
-			if leetcodeStats.TotalSolved == 0 && leetcodeStats.EasySolved == 0 && leetcodeStats.MediumSolved == 0 && leetcodeStats.HardSolved == 0 {
-				studentLogger.Error("Got zero values from LeetCode API",
-					zap.Any("stats", leetcodeStats),
-				)
-				failedCount++
-				continue
-			}
-
-			now := time.Now()
-			rating := &models.Rating{
-				StudentID:     student.ID,
-				ProblemsCount: leetcodeStats.TotalSolved,
-				EasyCount:     leetcodeStats.EasySolved,
-				MediumCount:   leetcodeStats.MediumSolved,
-				HardCount:     leetcodeStats.HardSolved,
-				GlobalRank:    leetcodeStats.ContestRanking,
-				RecordedAt:    now,
-				CreatedAt:     now,
-			}
-
-			// Calculate rating using the formula: Easy(x1) + Medium(x3) + Hard(x5) + 20% of contest rating
-			problemRating := (rating.EasyCount * 1) + (rating.MediumCount * 3) + (rating.HardCount * 5)
-			contestBonus := int(leetcodeStats.ContestRating * 0.2)
-			rating.Rating = problemRating + contestBonus
-
-			if err := h.service.UpdateStudentRating(student.ID, rating); err != nil {
-				studentLogger.Error("Failed to update student rating",
+			if _, err := h.jobs.Enqueue(c.Request.Context(), jobType, jobs.StudentPayload{StudentID: student.ID}); err != nil {
+				logger.Error("Failed to queue job",
 					zap.Error(err),
+					zap.Uint("internal_id", student.ID),
 				)
-				failedCount++
-				continue
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue jobs"})
+				return
 			}
-
-			studentLogger.Info("Successfully processed student",
-				zap.Int("rating", rating.Rating),
-				zap.Int("problems_count", rating.ProblemsCount),
-			)
-			processedCount++
-			totalProcessed++
-
-			// Add a small delay between students to avoid rate limiting
-			time.Sleep(500 * time.Millisecond)
+			queuedCount++
 		}
 
 		page++
 	}
 
 	duration := time.Since(start)
-	logger.Info("Rating update completed for all students",
+	logger.Info("Jobs queued for all students",
 		zap.Duration("duration", duration),
-		zap.Int("total_processed", processedCount),
-		zap.Int("failed_count", failedCount),
+		zap.Int("queued_count", queuedCount),
+		zap.Int("skipped_count", skippedCount),
 	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "Rating updates completed",
-		"processed_count": processedCount,
-		"failed_count":    failedCount,
-		"duration":        duration.String(),
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Updates queued",
+		"queued_count":  queuedCount,
+		"skipped_count": skippedCount,
 	})
 }
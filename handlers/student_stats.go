@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StudentStatsHandler serves a student's aggregated stats
+type StudentStatsHandler struct {
+	streaks      *service.StreakService
+	distribution *service.DistributionService
+	logger       *zap.Logger
+}
+
+// NewStudentStatsHandler creates a new student stats handler
+func NewStudentStatsHandler(streaks *service.StreakService, distribution *service.DistributionService, logger *zap.Logger) *StudentStatsHandler {
+	return &StudentStatsHandler{
+		streaks:      streaks,
+		distribution: distribution,
+		logger:       logger,
+	}
+}
+
+// GetStudentStats returns a student's rating, contest and weekly stats with
+// their streak, consistency score and percentiles among their peers
+func (h *StudentStatsHandler) GetStudentStats(c *gin.Context) {
+	start := time.Now()
+	studentID := c.Param("id")
+	logger := h.logger.With(
+		zap.String("handler", "GetStudentStats"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("student_id", studentID),
+	)
+
+	id, err := strconv.ParseUint(studentID, 10, 32)
+	if err != nil {
+		logger.Warn("Invalid student ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
+		return
+	}
+
+	stats, err := h.streaks.GetStudentStats(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+			return
+		}
+		logger.Error("Failed to get student stats",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get student stats: %v", err)})
+		return
+	}
+
+	stats.Percentiles, err = h.distribution.GetPercentiles(c.Request.Context(), uint(id))
+	if err != nil {
+		logger.Error("Failed to get student percentiles",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get student percentiles: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Student stats retrieved successfully",
+		zap.Duration("duration", duration),
+	)
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UploadHandler exposes the status and results of bulk student uploads
+type UploadHandler struct {
+	imports *service.ImportService
+	logger  *zap.Logger
+}
+
+// NewUploadHandler creates a new upload handler
+func NewUploadHandler(imports *service.ImportService, logger *zap.Logger) *UploadHandler {
+	return &UploadHandler{
+		imports: imports,
+		logger:  logger,
+	}
+}
+
+// ListUploads returns recent uploads, optionally filtered by status
+func (h *UploadHandler) ListUploads(c *gin.Context) {
+	start := time.Now()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize > service.MaxUploadPageSize {
+		pageSize = service.MaxUploadPageSize
+	}
+	status := c.Query("status")
+
+	logger := h.logger.With(
+		zap.String("handler", "ListUploads"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.Int("page", page),
+		zap.Int("page_size", pageSize),
+	)
+
+	uploads, err := h.imports.ListUploads(c.Request.Context(), page, pageSize, status)
+	if err != nil {
+		logger.Error("Failed to list uploads",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list uploads: %v", err)})
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Uploads listed successfully",
+		zap.Duration("duration", duration),
+		zap.Int("upload_count", len(uploads)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"uploads":   uploads,
+	})
+}
+
+// GetUpload returns the status and counts of a single upload
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "GetUpload"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("upload_id", c.Param("id")),
+	)
+
+	upload, ok := h.loadUpload(c, logger)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Upload retrieved successfully",
+		zap.Duration("duration", duration),
+		zap.String("status", upload.Status),
+	)
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// GetUploadErrors returns the failed rows of an upload as a CSV file that can
+// be corrected and uploaded again
+func (h *UploadHandler) GetUploadErrors(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "GetUploadErrors"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("upload_id", c.Param("id")),
+	)
+
+	upload, ok := h.loadUpload(c, logger)
+	if !ok {
+		return
+	}
+
+	if upload.Status == service.UploadStatusPending || upload.Status == service.UploadStatusProcessing {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload has not been processed yet"})
+		return
+	}
+
+	name := strings.TrimSuffix(upload.OriginalName, filepath.Ext(upload.OriginalName))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_errors.csv"`, name))
+
+	if err := h.imports.WriteErrorReport(c.Request.Context(), upload, c.Writer); err != nil {
+		logger.Error("Failed to write error report",
+			zap.Error(err),
+		)
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to build error report: %v", err)})
+		}
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Error report generated successfully",
+		zap.Duration("duration", duration),
+		zap.Int("failed_records", upload.FailedRecords),
+	)
+}
+
+// RollbackUpload undoes the student changes made by an upload, reporting rows
+// left alone because the student changed since
+func (h *UploadHandler) RollbackUpload(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "RollbackUpload"),
+		zap.String("request_id", c.GetString("request_id")),
+		zap.String("upload_id", c.Param("id")),
+	)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logger.Warn("Invalid upload ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload ID"})
+		return
+	}
+
+	result, err := h.imports.RollbackUpload(c.Request.Context(), uint(id), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+		case errors.Is(err, service.ErrUploadNotRollbackable):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			logger.Error("Failed to roll back upload",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to roll back upload: %v", err)})
+		}
+		return
+	}
+
+	duration := time.Since(start)
+	logger.Info("Upload rolled back successfully",
+		zap.Duration("duration", duration),
+		zap.Int("deleted", result.Deleted),
+		zap.Int("restored", result.Restored),
+		zap.Int("skipped", len(result.Skipped)),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// loadUpload parses the upload ID from the path and fetches the upload,
+// writing the error response itself when that fails
+func (h *UploadHandler) loadUpload(c *gin.Context, logger *zap.Logger) (*models.FileUpload, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		logger.Warn("Invalid upload ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload ID"})
+		return nil, false
+	}
+
+	upload, err := h.imports.GetUpload(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload not found"})
+			return nil, false
+		}
+		logger.Error("Failed to get upload",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get upload: %v", err)})
+		return nil, false
+	}
+	return upload, true
+}
@@ -1,39 +1,46 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/jobs"
 	"github.com/ayush/ORBIT/internal/models"
-	"github.com/ayush/ORBIT/pkg/leetcode"
+	"github.com/ayush/ORBIT/internal/service"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// defaultBackfillWeeks is how many weeks a backfill covers unless ?from= is given
+const defaultBackfillWeeks = 12
+
 // WeeklyStatsDB defines the database interface for weekly stats
 type WeeklyStatsDB interface {
 	GetStudentWeeklyStats(studentID string) ([]models.WeeklyStats, error)
-	GetWeeklyStats(studentID string, start, end time.Time) (*models.WeeklyStats, error)
 	UpdateWeeklyStats(stats *models.WeeklyStats) error
-	GetAllStudents() ([]*models.Student, error)
-	GetStudentByID(studentID string) (*models.Student, error)
 }
 
 // WeeklyStatsHandler handles weekly stats operations
 type WeeklyStatsHandler struct {
-	db     WeeklyStatsDB
-	lc     *leetcode.Client
-	logger *zap.Logger
+	db       WeeklyStatsDB
+	weekly   *service.WeeklyStatsService
+	jobs     JobEnqueuer
+	calendar *calendar.Calendar
+	logger   *zap.Logger
 }
 
 // NewWeeklyStatsHandler creates a new weekly stats handler
-func NewWeeklyStatsHandler(db WeeklyStatsDB, lc *leetcode.Client, logger *zap.Logger) *WeeklyStatsHandler {
+func NewWeeklyStatsHandler(db WeeklyStatsDB, weekly *service.WeeklyStatsService, jobs JobEnqueuer, cal *calendar.Calendar, logger *zap.Logger) *WeeklyStatsHandler {
 	return &WeeklyStatsHandler{
-		db:     db,
-		lc:     lc,
-		logger: logger,
+		db:       db,
+		weekly:   weekly,
+		jobs:     jobs,
+		calendar: cal,
+		logger:   logger,
 	}
 }
 
@@ -85,24 +92,18 @@ func (h *WeeklyStatsHandler) GetCurrentWeekStats(c *gin.Context) {
 
 	logger.Info("Starting current week stats retrieval")
 
-	if studentID == "" {
-		logger.Warn("Missing student ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "student ID is required"})
+	id, err := strconv.ParseUint(studentID, 10, 32)
+	if err != nil {
+		logger.Warn("Invalid student ID",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
 		return
 	}
 
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekEnd := weekStart.AddDate(0, 0, 7)
-
-	logger = logger.With(
-		zap.Time("week_start", weekStart),
-		zap.Time("week_end", weekEnd),
-	)
-
-	stats, err := h.db.GetWeeklyStats(studentID, weekStart, weekEnd)
+	stats, err := h.weekly.CurrentWeek(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "record not found" {
+		if errors.Is(err, service.ErrNotFound) {
 			logger.Info("No stats found for current week")
 			c.JSON(http.StatusNotFound, gin.H{"error": "no stats found for current week"})
 			return
@@ -177,7 +178,8 @@ func (h *WeeklyStatsHandler) UpdateWeeklyStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// UpdateWeeklyStatsFromLeetCode updates weekly stats from LeetCode data
+// UpdateWeeklyStatsFromLeetCode records a fresh LeetCode snapshot for a
+// student and returns their progress this week
 func (h *WeeklyStatsHandler) UpdateWeeklyStatsFromLeetCode(c *gin.Context) {
 	start := time.Now()
 	studentID := c.Param("id")
@@ -189,59 +191,51 @@ func (h *WeeklyStatsHandler) UpdateWeeklyStatsFromLeetCode(c *gin.Context) {
 
 	logger.Info("Starting LeetCode stats update")
 
-	student, err := h.db.GetStudentByID(studentID)
+	id, err := strconv.ParseUint(studentID, 10, 32)
 	if err != nil {
-		logger.Error("Failed to find student",
+		logger.Warn("Invalid student ID",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID"})
 		return
 	}
 
-	logger = logger.With(
-		zap.String("leetcode_id", student.LeetcodeID),
-	)
-
-	// Get LeetCode stats
-	logger.Info("Fetching LeetCode stats")
-	stats, err := h.lc.GetUserStats(student.LeetcodeID)
+	stats, err := h.weekly.SyncStudent(c.Request.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to fetch LeetCode stats",
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+			return
+		}
+		logger.Error("Failed to update weekly stats",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch LeetCode stats: %v", err)})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update weekly stats: %v", err)})
 		return
 	}
 
-	// Create weekly stats
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekEnd := weekStart.AddDate(0, 0, 7)
+	duration := time.Since(start)
+	logger.Info("Weekly stats updated from LeetCode successfully",
+		zap.Duration("duration", duration),
+		zap.Int("problems_solved", stats.ProblemsSolved),
+	)
 
-	parsedID, err := strconv.ParseUint(studentID, 10, 64)
-	if err != nil {
-		logger.Error("Invalid student ID format",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid student ID format"})
-		return
-	}
+	c.JSON(http.StatusOK, stats)
+}
 
-	weeklyStats := &models.WeeklyStats{
-		StudentID:      uint(parsedID),
-		WeekStart:      weekStart,
-		WeekEnd:        weekEnd,
-		ProblemsSolved: stats.TotalSolved,
-		EasySolved:     stats.EasyCount,
-		MediumSolved:   stats.MediumCount,
-		HardSolved:     stats.HardCount,
-		ContestRating:  float64(stats.ContestRating),
-		GlobalRanking:  stats.ContestRanking,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-	}
+// UpdateAllWeeklyStats recomputes the current week's stats of every student
+// from their rating snapshots
+func (h *WeeklyStatsHandler) UpdateAllWeeklyStats(c *gin.Context) {
+	start := time.Now()
+	logger := h.logger.With(
+		zap.String("handler", "UpdateAllWeeklyStats"),
+		zap.String("request_id", c.GetString("request_id")),
+	)
 
-	if err := h.db.UpdateWeeklyStats(weeklyStats); err != nil {
+	logger.Info("Starting weekly stats update for all students")
+
+	weekStart := h.calendar.WeekStart(start)
+	processedCount, err := h.weekly.ComputeWeek(c.Request.Context(), weekStart)
+	if err != nil {
 		logger.Error("Failed to update weekly stats",
 			zap.Error(err),
 		)
@@ -250,133 +244,73 @@ func (h *WeeklyStatsHandler) UpdateWeeklyStatsFromLeetCode(c *gin.Context) {
 	}
 
 	duration := time.Since(start)
-	logger.Info("Weekly stats updated from LeetCode successfully",
+	logger.Info("Weekly stats update completed for all students",
 		zap.Duration("duration", duration),
-		zap.Int("problems_count", stats.TotalSolved),
-		zap.Int("contest_rating", stats.ContestRating),
+		zap.Int("total_processed", processedCount),
 	)
 
-	c.JSON(http.StatusOK, weeklyStats)
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Weekly stats update completed",
+		"week_start":      h.calendar.FormatDate(weekStart),
+		"processed_count": processedCount,
+		"duration":        duration.String(),
+	})
 }
 
-// UpdateAllWeeklyStats updates weekly stats for all students
-func (h *WeeklyStatsHandler) UpdateAllWeeklyStats(c *gin.Context) {
+// BackfillWeeklyStats queues a recomputation of past weeks from ?from= to
+// ?to= (YYYY-MM-DD). Without ?from= it covers the last 12 weeks; ?to=
+// defaults to today.
+func (h *WeeklyStatsHandler) BackfillWeeklyStats(c *gin.Context) {
 	start := time.Now()
 	logger := h.logger.With(
-		zap.String("handler", "UpdateAllWeeklyStats"),
+		zap.String("handler", "BackfillWeeklyStats"),
 		zap.String("request_id", c.GetString("request_id")),
 	)
 
-	logger.Info("Starting weekly stats update for all students")
-
-	// Get all students (paginated)
-	page := 1
-	pageSize := 10
-	var processedCount int
-	var failedCount int
-	var totalProcessed int
-
-	for {
-		logger.Info("Processing student batch",
-			zap.Int("page", page),
-			zap.Int("page_size", pageSize),
-			zap.Int("processed_so_far", totalProcessed),
-		)
-
-		students, err := h.db.GetAllStudents()
+	to := h.calendar.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := h.calendar.ParseDate(value)
 		if err != nil {
-			logger.Error("Failed to fetch students",
-				zap.Error(err),
-				zap.Int("page", page),
-			)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch students"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
 			return
 		}
-
-		if len(students) == 0 {
-			break // No more students to process
-		}
-
-		// Process each student in the batch
-		for _, student := range students {
-			studentLogger := logger.With(
-				zap.String("student_id", student.StudentID),
-				zap.String("leetcode_id", student.LeetcodeID),
-			)
-
-			studentLogger.Info("Processing student")
-
-			// Get LeetCode stats
-			stats, err := h.lc.GetUserStats(student.LeetcodeID)
-			if err != nil {
-				studentLogger.Error("Failed to fetch LeetCode stats",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			// Create weekly stats
-			now := time.Now()
-			weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-			weekEnd := weekStart.AddDate(0, 0, 7)
-
-			parsedID, err := strconv.ParseUint(student.StudentID, 10, 64)
-			if err != nil {
-				studentLogger.Error("Invalid student ID format",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			weeklyStats := &models.WeeklyStats{
-				StudentID:      uint(parsedID),
-				WeekStart:      weekStart,
-				WeekEnd:        weekEnd,
-				ProblemsSolved: stats.TotalSolved,
-				EasySolved:     stats.EasyCount,
-				MediumSolved:   stats.MediumCount,
-				HardSolved:     stats.HardCount,
-				ContestRating:  float64(stats.ContestRating),
-				GlobalRanking:  stats.ContestRanking,
-				CreatedAt:      now,
-				UpdatedAt:      now,
-			}
-
-			if err := h.db.UpdateWeeklyStats(weeklyStats); err != nil {
-				studentLogger.Error("Failed to update weekly stats",
-					zap.Error(err),
-				)
-				failedCount++
-				continue
-			}
-
-			studentLogger.Info("Successfully updated weekly stats",
-				zap.Int("problems_count", stats.TotalSolved),
-				zap.Int("contest_rating", stats.ContestRating),
-			)
-			processedCount++
-			totalProcessed++
-
-			// Add a small delay between students to avoid rate limiting
-			time.Sleep(500 * time.Millisecond)
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -7*(defaultBackfillWeeks-1))
+	if value := c.Query("from"); value != "" {
+		parsed, err := h.calendar.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+			return
 		}
+		from = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
 
-		page++
+	job, err := h.jobs.Enqueue(c.Request.Context(), jobs.TypeBackfillWeeklyStats, jobs.BackfillWeeklyStatsPayload{From: from, To: to})
+	if err != nil {
+		logger.Error("Failed to queue weekly stats backfill",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue weekly stats backfill"})
+		return
 	}
 
 	duration := time.Since(start)
-	logger.Info("Weekly stats update completed for all students",
+	logger.Info("Weekly stats backfill queued successfully",
 		zap.Duration("duration", duration),
-		zap.Int("total_processed", processedCount),
-		zap.Int("failed_count", failedCount),
+		zap.Uint("job_id", job.ID),
+		zap.Time("from", from),
+		zap.Time("to", to),
 	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "Weekly stats update completed",
-		"processed_count": processedCount,
-		"failed_count":    failedCount,
-		"duration":        duration.String(),
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Weekly stats backfill queued",
+		"job_id":  job.ID,
+		"from":    h.calendar.FormatDate(h.calendar.WeekStart(from)),
+		"to":      h.calendar.FormatDate(h.calendar.WeekStart(to)),
 	})
 }
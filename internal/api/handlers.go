@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/ayush/ORBIT/internal/calendar"
 	"github.com/ayush/ORBIT/internal/models"
 	"github.com/ayush/ORBIT/internal/repository"
 	"github.com/ayush/ORBIT/internal/service"
@@ -25,12 +26,12 @@ func NewHandler(studentService *service.StudentService, logger *zap.Logger) *Han
 	}
 }
 
-func InitializeRoutes(router *gin.Engine, cfg interface{}, logger *zap.Logger, db *gorm.DB) {
+func InitializeRoutes(router *gin.Engine, cfg interface{}, cal *calendar.Calendar, logger *zap.Logger, db *gorm.DB) {
 	router.Use(gin.Recovery())
 	router.Use(corsMiddleware())
 
 	studentRepo := repository.NewStudentRepository(db)
-	studentService := service.NewStudentService(studentRepo, logger)
+	studentService := service.NewStudentService(studentRepo, cal, logger)
 	handler := NewHandler(studentService, logger)
 
 	v1 := router.Group("/api/v1")
@@ -171,6 +171,20 @@ func (c *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error)
 	return c.client.Keys(ctx, pattern).Result()
 }
 
+// Scan gets all keys matching a pattern, iterating with SCAN so the server is
+// not blocked the way KEYS blocks it
+func (c *RedisCache) Scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
 // Type gets the type of a key
 func (c *RedisCache) Type(ctx context.Context, key string) (string, error) {
 	return c.client.Type(ctx, key).Result()
@@ -207,3 +221,93 @@ func (c *RedisCache) ExistsMulti(ctx context.Context, keys []string) (map[string
 
 	return result, nil
 }
+
+// ScoredMember is a sorted set member with its score
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// ZAdd sets the score of a member in a sorted set
+func (c *RedisCache) ZAdd(ctx context.Context, key, member string, score float64) error {
+	return c.client.ZAdd(ctx, key, &redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZAddMulti sets the scores of several members of a sorted set
+func (c *RedisCache) ZAddMulti(ctx context.Context, key string, members []ScoredMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]*redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = &redis.Z{Score: m.Score, Member: m.Member}
+	}
+	return c.client.ZAdd(ctx, key, zs...).Err()
+}
+
+// ZRem removes members from a sorted set
+func (c *RedisCache) ZRem(ctx context.Context, key string, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, m := range members {
+		values[i] = m
+	}
+	return c.client.ZRem(ctx, key, values...).Err()
+}
+
+// ZScore gets the score of a member; it returns redis.Nil if the member is absent
+func (c *RedisCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return c.client.ZScore(ctx, key, member).Result()
+}
+
+// ZRevRank gets the 0-based position of a member, highest score first; it
+// returns redis.Nil if the member is absent
+func (c *RedisCache) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return c.client.ZRevRank(ctx, key, member).Result()
+}
+
+// ZRevRangeWithScores gets members from start to stop (inclusive, 0-based),
+// highest score first
+func (c *RedisCache) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]ScoredMember, error) {
+	zs, err := c.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		members[i] = ScoredMember{Member: member, Score: z.Score}
+	}
+	return members, nil
+}
+
+// ZCard gets the number of members in a sorted set
+func (c *RedisCache) ZCard(ctx context.Context, key string) (int64, error) {
+	return c.client.ZCard(ctx, key).Result()
+}
+
+// Rename atomically replaces dst with src
+func (c *RedisCache) Rename(ctx context.Context, src, dst string) error {
+	return c.client.Rename(ctx, src, dst).Err()
+}
+
+// IsNil reports whether err means the key or member does not exist
+func IsNil(err error) bool {
+	return err == redis.Nil
+}
+
+// Publish sends a JSON-encoded message to a pub/sub channel
+func (c *RedisCache) Publish(ctx context.Context, channel string, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Publish(ctx, channel, data).Err()
+}
+
+// Subscribe listens on pub/sub channels. The subscription reconnects on its
+// own after connection errors; callers must Close it when done.
+func (c *RedisCache) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return c.client.Subscribe(ctx, channels...)
+}
@@ -0,0 +1,151 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Timezones must load in minimal containers without system zoneinfo
+	_ "time/tzdata"
+)
+
+// dateLayout is the format of dates in query parameters and reports
+const dateLayout = "2006-01-02"
+
+// Calendar places times in the institution's timezone and academic week.
+// Every day and week boundary in the application comes from it, so weekly
+// stats, reports, leaderboards and schedulers agree on when a week begins.
+// It also knows when the academic year starts and how long programmes last,
+// which places a student's enrolment from their passing year.
+type Calendar struct {
+	location          *time.Location
+	weekStart         time.Weekday
+	academicYearStart time.Month
+	programmeYears    int
+}
+
+// New creates a calendar for an IANA timezone such as "Asia/Kolkata", the
+// weekday weeks start on, such as "monday", the month the academic year
+// starts in, such as "august", and the length of a programme in years
+func New(timezone, weekStart, academicYearStart string, programmeYears int) (*Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	day, err := ParseWeekday(weekStart)
+	if err != nil {
+		return nil, err
+	}
+	month, err := ParseMonth(academicYearStart)
+	if err != nil {
+		return nil, err
+	}
+	if programmeYears < 1 {
+		return nil, fmt.Errorf("invalid programme length %d: must be at least one year", programmeYears)
+	}
+	return &Calendar{
+		location:          location,
+		weekStart:         day,
+		academicYearStart: month,
+		programmeYears:    programmeYears,
+	}, nil
+}
+
+// ParseWeekday parses a weekday name, full or abbreviated, in any case
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid week start day %q", name)
+}
+
+// ParseMonth parses a month name, full or abbreviated, in any case
+func ParseMonth(name string) (time.Month, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for month := time.January; month <= time.December; month++ {
+		full := strings.ToLower(month.String())
+		if name == full || name == full[:3] {
+			return month, nil
+		}
+	}
+	return time.January, fmt.Errorf("invalid academic year start month %q", name)
+}
+
+// Location is the institution's timezone
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+// Timezone is the name of the institution's timezone
+func (c *Calendar) Timezone() string {
+	return c.location.String()
+}
+
+// WeekStartDay is the weekday weeks start on
+func (c *Calendar) WeekStartDay() time.Weekday {
+	return c.weekStart
+}
+
+// AcademicYearStart is the month the academic year starts in
+func (c *Calendar) AcademicYearStart() time.Month {
+	return c.academicYearStart
+}
+
+// ProgrammeYears is how many years a programme lasts
+func (c *Calendar) ProgrammeYears() int {
+	return c.programmeYears
+}
+
+// Enrolment returns the start of the academic year in which students
+// graduating in passingYear enrolled
+func (c *Calendar) Enrolment(passingYear int) time.Time {
+	return time.Date(passingYear-c.programmeYears, c.academicYearStart, 1, 0, 0, 0, 0, c.location)
+}
+
+// Now is the current time in the institution's timezone
+func (c *Calendar) Now() time.Time {
+	return time.Now().In(c.location)
+}
+
+// StartOfDay returns midnight of the institution's day containing t
+func (c *Calendar) StartOfDay(t time.Time) time.Time {
+	t = t.In(c.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+}
+
+// WeekStart returns midnight of the first day of the week containing t
+func (c *Calendar) WeekStart(t time.Time) time.Time {
+	day := c.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(c.weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// WeekEnd returns the start of the week after the one containing t, which
+// is the exclusive end of t's week
+func (c *Calendar) WeekEnd(t time.Time) time.Time {
+	return c.WeekStart(t).AddDate(0, 0, 7)
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight in the institution's timezone
+func (c *Calendar) ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, value, c.location)
+}
+
+// FormatDate formats the institution's date of t as YYYY-MM-DD
+func (c *Calendar) FormatDate(t time.Time) string {
+	return t.In(c.location).Format(dateLayout)
+}
+
+// DaysBetween returns the number of institution days from the day containing
+// from to the day containing to, counting calendar days so DST changes do not
+// shift the result
+func (c *Calendar) DaysBetween(from, to time.Time) int {
+	from, to = from.In(c.location), to.In(c.location)
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,215 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("time.LoadLocation(%q) error = %v", name, err)
+	}
+	return location
+}
+
+func TestWeekStartAndEnd(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	kolkata := mustLoad(t, "Asia/Kolkata")
+
+	tests := []struct {
+		name      string
+		timezone  string
+		weekStart string
+		at        time.Time
+		start     time.Time
+		end       time.Time
+	}{
+		{
+			name:      "midweek",
+			timezone:  "UTC",
+			weekStart: "monday",
+			at:        time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC),
+			start:     time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "first day of the week",
+			timezone:  "UTC",
+			weekStart: "monday",
+			at:        time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			start:     time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "last day of the week",
+			timezone:  "UTC",
+			weekStart: "monday",
+			at:        time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC),
+			start:     time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weeks starting on sunday",
+			timezone:  "UTC",
+			weekStart: "sun",
+			at:        time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC),
+			start:     time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day taken from the institution's timezone",
+			timezone:  "Asia/Kolkata",
+			weekStart: "monday",
+			// Sunday evening in UTC is already Monday in Kolkata
+			at:    time.Date(2026, 3, 8, 20, 0, 0, 0, time.UTC),
+			start: time.Date(2026, 3, 9, 0, 0, 0, 0, kolkata),
+			end:   time.Date(2026, 3, 16, 0, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "week spanning the start of DST",
+			timezone:  "America/New_York",
+			weekStart: "monday",
+			at:        time.Date(2026, 3, 8, 12, 0, 0, 0, newYork),
+			start:     time.Date(2026, 3, 2, 0, 0, 0, 0, newYork),
+			end:       time.Date(2026, 3, 9, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "week spanning the end of DST",
+			timezone:  "America/New_York",
+			weekStart: "sunday",
+			at:        time.Date(2026, 11, 4, 12, 0, 0, 0, newYork),
+			start:     time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			end:       time.Date(2026, 11, 8, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := New(tt.timezone, tt.weekStart, "august", 4)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := cal.WeekStart(tt.at); !got.Equal(tt.start) {
+				t.Errorf("WeekStart() = %v, want %v", got, tt.start)
+			}
+			if got := cal.WeekEnd(tt.at); !got.Equal(tt.end) {
+				t.Errorf("WeekEnd() = %v, want %v", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	cal, err := New("America/New_York", "monday", "august", 4)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	newYork := cal.Location()
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{
+			name: "same day",
+			from: time.Date(2026, 3, 11, 0, 0, 0, 0, newYork),
+			to:   time.Date(2026, 3, 11, 23, 59, 0, 0, newYork),
+			want: 0,
+		},
+		{
+			name: "across midnight",
+			from: time.Date(2026, 3, 11, 23, 59, 0, 0, newYork),
+			to:   time.Date(2026, 3, 12, 0, 1, 0, 0, newYork),
+			want: 1,
+		},
+		{
+			name: "backwards",
+			from: time.Date(2026, 3, 12, 9, 0, 0, 0, newYork),
+			to:   time.Date(2026, 3, 10, 9, 0, 0, 0, newYork),
+			want: -2,
+		},
+		{
+			// Only 23 hours pass between the two times
+			name: "across the start of DST",
+			from: time.Date(2026, 3, 7, 23, 30, 0, 0, newYork),
+			to:   time.Date(2026, 3, 8, 23, 30, 0, 0, newYork),
+			want: 1,
+		},
+		{
+			// 48 hours pass between the two times
+			name: "across the end of DST",
+			from: time.Date(2026, 10, 31, 0, 30, 0, 0, newYork),
+			to:   time.Date(2026, 11, 1, 23, 30, 0, 0, newYork),
+			want: 1,
+		},
+		{
+			name: "days taken from the institution's timezone",
+			// 02:00 UTC on the 12th is still the 11th in New York
+			from: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, 3, 12, 2, 0, 0, 0, time.UTC),
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.DaysBetween(tt.from, tt.to); got != tt.want {
+				t.Errorf("DaysBetween() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrolment(t *testing.T) {
+	kolkata := mustLoad(t, "Asia/Kolkata")
+
+	tests := []struct {
+		name              string
+		timezone          string
+		academicYearStart string
+		programmeYears    int
+		passingYear       int
+		want              time.Time
+	}{
+		{
+			name:              "four year programme",
+			timezone:          "UTC",
+			academicYearStart: "august",
+			programmeYears:    4,
+			passingYear:       2028,
+			want:              time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "two year programme starting in july",
+			timezone:          "UTC",
+			academicYearStart: "jul",
+			programmeYears:    2,
+			passingYear:       2027,
+			want:              time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:              "midnight in the institution's timezone",
+			timezone:          "Asia/Kolkata",
+			academicYearStart: "june",
+			programmeYears:    4,
+			passingYear:       2026,
+			want:              time.Date(2022, 6, 1, 0, 0, 0, 0, kolkata),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := New(tt.timezone, "monday", tt.academicYearStart, tt.programmeYears)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := cal.Enrolment(tt.passingYear); !got.Equal(tt.want) {
+				t.Errorf("Enrolment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
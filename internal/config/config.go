@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds all configuration for the application
@@ -12,6 +14,44 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	// Institution calendar: IANA timezone, the weekday weeks start on, the
+	// month the academic year starts in and the length of a programme, which
+	// together place a student's enrolment from their passing year
+	Timezone          string
+	WeekStartDay      string
+	AcademicYearStart string
+	ProgrammeYears    int
+
+	// Job queue consumers (worker only)
+	QueueWorkers int
+
+	// Optional JSON file with extra header aliases for student imports
+	ImportAliasesFile string
+
+	// LeetCode ID verification during import
+	VerifyRequestsPerMinute int
+	VerifyCacheTTL          time.Duration
+	VerifyCacheSize         int
+
+	// Stats refresh queue
+	RefreshActiveInterval  time.Duration
+	RefreshDormantInterval time.Duration
+	RefreshActiveWindow    time.Duration
+	RefreshBudgetPerHour   int
+
+	// Batch, department and system stats aggregation
+	StatsAggregateDelay    time.Duration
+	StatsAggregateInterval time.Duration
+
+	// At-risk detection: how long a student may go without solves or rating
+	// changes, and how often students are re-evaluated
+	InactivityWindow        time.Duration
+	InactivityCheckInterval time.Duration
 }
 
 // DefaultConfig returns a Config with default values
@@ -23,6 +63,30 @@ func DefaultConfig() *Config {
 		DBUser:     "postgres",
 		DBPassword: "postgres",
 		DBName:     "orbit",
+
+		RedisAddr: "localhost:6379",
+
+		Timezone:          "Asia/Kolkata",
+		WeekStartDay:      "sunday",
+		AcademicYearStart: "august",
+		ProgrammeYears:    4,
+
+		QueueWorkers: 2,
+
+		VerifyRequestsPerMinute: 30,
+		VerifyCacheTTL:          24 * time.Hour,
+		VerifyCacheSize:         10000,
+
+		RefreshActiveInterval:  24 * time.Hour,
+		RefreshDormantInterval: 7 * 24 * time.Hour,
+		RefreshActiveWindow:    7 * 24 * time.Hour,
+		RefreshBudgetPerHour:   600,
+
+		StatsAggregateDelay:    15 * time.Minute,
+		StatsAggregateInterval: 6 * time.Hour,
+
+		InactivityWindow:        14 * 24 * time.Hour,
+		InactivityCheckInterval: 6 * time.Hour,
 	}
 }
 
@@ -49,6 +113,32 @@ func Load() *Config {
 		cfg.DBName = name
 	}
 
+	cfg.RedisAddr = getEnvOrDefault("REDIS_ADDR", cfg.RedisAddr)
+	cfg.RedisPassword = getEnvOrDefault("REDIS_PASSWORD", cfg.RedisPassword)
+	cfg.RedisDB = getIntOrDefault("REDIS_DB", cfg.RedisDB)
+
+	cfg.Timezone = getEnvOrDefault("INSTITUTION_TIMEZONE", cfg.Timezone)
+	cfg.WeekStartDay = getEnvOrDefault("WEEK_START_DAY", cfg.WeekStartDay)
+	cfg.AcademicYearStart = getEnvOrDefault("ACADEMIC_YEAR_START_MONTH", cfg.AcademicYearStart)
+	cfg.ProgrammeYears = getIntOrDefault("PROGRAMME_YEARS", cfg.ProgrammeYears)
+
+	cfg.QueueWorkers = getIntOrDefault("QUEUE_WORKERS", cfg.QueueWorkers)
+	cfg.ImportAliasesFile = getEnvOrDefault("IMPORT_COLUMN_ALIASES_FILE", cfg.ImportAliasesFile)
+	cfg.VerifyRequestsPerMinute = getIntOrDefault("IMPORT_VERIFY_REQUESTS_PER_MINUTE", cfg.VerifyRequestsPerMinute)
+	cfg.VerifyCacheTTL = getDurationOrDefault("IMPORT_VERIFY_CACHE_TTL", cfg.VerifyCacheTTL)
+	cfg.VerifyCacheSize = getIntOrDefault("IMPORT_VERIFY_CACHE_SIZE", cfg.VerifyCacheSize)
+
+	cfg.RefreshActiveInterval = getDurationOrDefault("REFRESH_ACTIVE_INTERVAL", cfg.RefreshActiveInterval)
+	cfg.RefreshDormantInterval = getDurationOrDefault("REFRESH_DORMANT_INTERVAL", cfg.RefreshDormantInterval)
+	cfg.RefreshActiveWindow = getDurationOrDefault("REFRESH_ACTIVE_WINDOW", cfg.RefreshActiveWindow)
+	cfg.RefreshBudgetPerHour = getIntOrDefault("REFRESH_BUDGET_PER_HOUR", cfg.RefreshBudgetPerHour)
+
+	cfg.StatsAggregateDelay = getDurationOrDefault("STATS_AGGREGATE_DELAY", cfg.StatsAggregateDelay)
+	cfg.StatsAggregateInterval = getDurationOrDefault("STATS_AGGREGATE_INTERVAL", cfg.StatsAggregateInterval)
+
+	cfg.InactivityWindow = getDurationOrDefault("INACTIVITY_WINDOW", cfg.InactivityWindow)
+	cfg.InactivityCheckInterval = getDurationOrDefault("INACTIVITY_CHECK_INTERVAL", cfg.InactivityCheckInterval)
+
 	return cfg
 }
 
@@ -59,3 +149,23 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationOrDefault parses an environment variable as a duration such as "24h"
+func getDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getIntOrDefault parses an environment variable as an integer
+func getIntOrDefault(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
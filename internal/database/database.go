@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ayush/ORBIT/internal/leetcode"
 	"github.com/ayush/ORBIT/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Database struct {
@@ -245,6 +247,30 @@ func (d *FileUploadDB) GetByID(ctx context.Context, id uint) (*models.FileUpload
 	return &upload, nil
 }
 
+// LockByID fetches an upload and locks its row until the surrounding
+// transaction ends
+func (d *FileUploadDB) LockByID(ctx context.Context, id uint) (*models.FileUpload, error) {
+	var upload models.FileUpload
+	if err := d.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&upload, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock file upload: %w", err)
+	}
+	return &upload, nil
+}
+
+// List returns uploads newest first, optionally filtered by status
+func (d *FileUploadDB) List(ctx context.Context, offset, limit int, status string) ([]models.FileUpload, error) {
+	query := d.db.WithContext(ctx).Order("created_at DESC").Offset(offset).Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var uploads []models.FileUpload
+	if err := query.Find(&uploads).Error; err != nil {
+		return nil, fmt.Errorf("failed to list file uploads: %w", err)
+	}
+	return uploads, nil
+}
+
 func (d *FileUploadDB) Update(ctx context.Context, upload *models.FileUpload) error {
 	return d.db.WithContext(ctx).Save(upload).Error
 }
@@ -253,6 +279,124 @@ func (d *FileUploadDB) Delete(ctx context.Context, id uint) error {
 	return d.db.WithContext(ctx).Delete(&models.FileUpload{}, id).Error
 }
 
+// importRowBatchSize is the number of row results written per insert
+const importRowBatchSize = 500
+
+type ImportRowDB struct {
+	db *gorm.DB
+}
+
+func NewImportRowDB(db *gorm.DB) *ImportRowDB {
+	return &ImportRowDB{db: db}
+}
+
+// CreateBatch stores the row results of an upload
+func (d *ImportRowDB) CreateBatch(ctx context.Context, rows []models.ImportRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := d.db.WithContext(ctx).CreateInBatches(rows, importRowBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to save import rows: %w", err)
+	}
+	return nil
+}
+
+// ListByUpload returns the row results of an upload in file order
+func (d *ImportRowDB) ListByUpload(ctx context.Context, uploadID uint) ([]models.ImportRow, error) {
+	var rows []models.ImportRow
+	if err := d.db.WithContext(ctx).Where("file_upload_id = ?", uploadID).Order("row_number").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list import rows: %w", err)
+	}
+	return rows, nil
+}
+
+// ListFailedByUpload returns the failed rows of an upload in file order
+func (d *ImportRowDB) ListFailedByUpload(ctx context.Context, uploadID uint) ([]models.ImportRow, error) {
+	var rows []models.ImportRow
+	err := d.db.WithContext(ctx).
+		Where("file_upload_id = ? AND outcome = ?", uploadID, models.ImportRowFailed).
+		Order("row_number").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed import rows: %w", err)
+	}
+	return rows, nil
+}
+
+// ListAppliedByUpload returns the rows of an upload that created or updated a
+// student, last row first so they can be undone in reverse order
+func (d *ImportRowDB) ListAppliedByUpload(ctx context.Context, uploadID uint) ([]models.ImportRow, error) {
+	var rows []models.ImportRow
+	err := d.db.WithContext(ctx).
+		Where("file_upload_id = ? AND outcome IN ?", uploadID, []string{models.ImportRowCreated, models.ImportRowUpdated}).
+		Order("row_number DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied import rows: %w", err)
+	}
+	return rows, nil
+}
+
+type AuditLogDB struct {
+	db *gorm.DB
+}
+
+func NewAuditLogDB(db *gorm.DB) *AuditLogDB {
+	return &AuditLogDB{db: db}
+}
+
+func (d *AuditLogDB) Create(ctx context.Context, entry *models.AuditLog) error {
+	if err := d.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+type WeeklyReportDB struct {
+	db *gorm.DB
+}
+
+func NewWeeklyReportDB(db *gorm.DB) *WeeklyReportDB {
+	return &WeeklyReportDB{db: db}
+}
+
+func (d *WeeklyReportDB) Create(ctx context.Context, report *models.WeeklyReport) error {
+	if err := d.db.WithContext(ctx).Create(report).Error; err != nil {
+		return fmt.Errorf("failed to save weekly report: %w", err)
+	}
+	return nil
+}
+
+func (d *WeeklyReportDB) GetByID(ctx context.Context, id uint) (*models.WeeklyReport, error) {
+	var report models.WeeklyReport
+	if err := d.db.WithContext(ctx).First(&report, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get weekly report: %w", err)
+	}
+	return &report, nil
+}
+
+// List returns stored reports newest week first, without their HTML,
+// optionally filtered by batch and department
+func (d *WeeklyReportDB) List(ctx context.Context, offset, limit int, batch, department string) ([]models.WeeklyReport, error) {
+	query := d.db.WithContext(ctx).
+		Select("id, batch, department, week_start, week_end, created_at").
+		Order("week_start DESC, created_at DESC").
+		Offset(offset).
+		Limit(limit)
+	if batch != "" {
+		query = query.Where("batch = ?", batch)
+	}
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+
+	var reports []models.WeeklyReport
+	if err := query.Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list weekly reports: %w", err)
+	}
+	return reports, nil
+}
+
 type BatchStatsDB struct {
 	db *gorm.DB
 }
@@ -265,14 +409,28 @@ func (d *BatchStatsDB) Create(ctx context.Context, stats *models.BatchStats) err
 	return d.db.WithContext(ctx).Create(stats).Error
 }
 
+// GetByBatch returns the latest snapshot of a batch
 func (d *BatchStatsDB) GetByBatch(ctx context.Context, batch string) (*models.BatchStats, error) {
 	var stats models.BatchStats
-	if err := d.db.WithContext(ctx).Where("batch = ?", batch).First(&stats).Error; err != nil {
+	if err := d.db.WithContext(ctx).Where("batch = ?", batch).Order("created_at DESC").First(&stats).Error; err != nil {
 		return nil, fmt.Errorf("failed to get batch stats: %w", err)
 	}
 	return &stats, nil
 }
 
+// ListByBatch returns the snapshots of a batch taken since the given time, oldest first
+func (d *BatchStatsDB) ListByBatch(ctx context.Context, batch string, since time.Time) ([]models.BatchStats, error) {
+	var history []models.BatchStats
+	err := d.db.WithContext(ctx).
+		Where("batch = ? AND created_at >= ?", batch, since).
+		Order("created_at").
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch stats: %w", err)
+	}
+	return history, nil
+}
+
 func (d *BatchStatsDB) Update(ctx context.Context, stats *models.BatchStats) error {
 	return d.db.WithContext(ctx).Save(stats).Error
 }
@@ -293,14 +451,29 @@ func (d *DepartmentStatsDB) Create(ctx context.Context, stats *models.Department
 	return d.db.WithContext(ctx).Create(stats).Error
 }
 
+// GetByDepartment returns the latest snapshot of a department
 func (d *DepartmentStatsDB) GetByDepartment(ctx context.Context, department string) (*models.DepartmentStats, error) {
 	var stats models.DepartmentStats
-	if err := d.db.WithContext(ctx).Where("department = ?", department).First(&stats).Error; err != nil {
+	if err := d.db.WithContext(ctx).Where("department = ?", department).Order("created_at DESC").First(&stats).Error; err != nil {
 		return nil, fmt.Errorf("failed to get department stats: %w", err)
 	}
 	return &stats, nil
 }
 
+// ListByDepartment returns the snapshots of a department taken since the given
+// time, oldest first
+func (d *DepartmentStatsDB) ListByDepartment(ctx context.Context, department string, since time.Time) ([]models.DepartmentStats, error) {
+	var history []models.DepartmentStats
+	err := d.db.WithContext(ctx).
+		Where("department = ? AND created_at >= ?", department, since).
+		Order("created_at").
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list department stats: %w", err)
+	}
+	return history, nil
+}
+
 func (d *DepartmentStatsDB) Update(ctx context.Context, stats *models.DepartmentStats) error {
 	return d.db.WithContext(ctx).Save(stats).Error
 }
@@ -329,6 +502,15 @@ func (d *SystemStatsDB) GetLatest(ctx context.Context) (*models.SystemStats, err
 	return &stats, nil
 }
 
+// List returns the system snapshots taken since the given time, oldest first
+func (d *SystemStatsDB) List(ctx context.Context, since time.Time) ([]models.SystemStats, error) {
+	var history []models.SystemStats
+	if err := d.db.WithContext(ctx).Where("created_at >= ?", since).Order("created_at").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to list system stats: %w", err)
+	}
+	return history, nil
+}
+
 func (d *SystemStatsDB) Update(ctx context.Context, stats *models.SystemStats) error {
 	return d.db.WithContext(ctx).Save(stats).Error
 }
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLeaseLost is returned when a job is no longer running under the attempt
+// that tried to update it, because the reaper handed it to another worker
+var ErrLeaseLost = errors.New("job lease lost")
+
+// JobDB handles persistence for the background job queue
+type JobDB struct {
+	db *gorm.DB
+}
+
+// NewJobDB creates a new JobDB instance
+func NewJobDB(db *gorm.DB) *JobDB {
+	return &JobDB{db: db}
+}
+
+// Create inserts a new job
+func (d *JobDB) Create(ctx context.Context, job *models.Job) error {
+	return d.db.WithContext(ctx).Create(job).Error
+}
+
+// GetByID retrieves a job by its ID
+func (d *JobDB) GetByID(ctx context.Context, id uint) (*models.Job, error) {
+	var job models.Job
+	if err := d.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return &job, nil
+}
+
+// Claim locks the oldest runnable job of one of the given types and marks it
+// running until the visibility timeout expires. It returns nil when nothing is due.
+func (d *JobDB) Claim(ctx context.Context, types []string, visibility time.Duration) (*models.Job, error) {
+	var job models.Job
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= NOW() AND type IN ?", models.JobStatusPending, types).
+			Order("run_at ASC").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		lockedUntil := time.Now().Add(visibility)
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedUntil = &lockedUntil
+
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_until": job.LockedUntil,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return &job, nil
+}
+
+// ExtendLock pushes out the visibility timeout of a running attempt of a job
+func (d *JobDB) ExtendLock(ctx context.Context, id uint, attempt int, visibility time.Duration) error {
+	return d.updateAttempt(ctx, id, attempt, map[string]interface{}{
+		"locked_until": time.Now().Add(visibility),
+	})
+}
+
+// Complete marks a running attempt of a job as finished
+func (d *JobDB) Complete(ctx context.Context, id uint, attempt int) error {
+	return d.updateAttempt(ctx, id, attempt, map[string]interface{}{
+		"status":       models.JobStatusCompleted,
+		"locked_until": nil,
+		"completed_at": time.Now(),
+	})
+}
+
+// Retry puts a failed attempt of a job back in the queue to run again at runAt
+func (d *JobDB) Retry(ctx context.Context, id uint, attempt int, runAt time.Time, lastError string) error {
+	return d.updateAttempt(ctx, id, attempt, map[string]interface{}{
+		"status":       models.JobStatusPending,
+		"run_at":       runAt,
+		"locked_until": nil,
+		"last_error":   lastError,
+	})
+}
+
+// Bury moves a job to the dead-letter state after its last attempt failed
+func (d *JobDB) Bury(ctx context.Context, id uint, attempt int, lastError string) error {
+	return d.updateAttempt(ctx, id, attempt, map[string]interface{}{
+		"status":       models.JobStatusDead,
+		"locked_until": nil,
+		"last_error":   lastError,
+	})
+}
+
+// updateAttempt updates a job only while the given attempt still holds it,
+// and returns ErrLeaseLost otherwise
+func (d *JobDB) updateAttempt(ctx context.Context, id uint, attempt int, updates map[string]interface{}) error {
+	result := d.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, models.JobStatusRunning, attempt).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: job %d attempt %d", ErrLeaseLost, id, attempt)
+	}
+	return nil
+}
+
+// ListExpired returns running jobs whose visibility timeout has passed, which
+// means the worker holding them died
+func (d *JobDB) ListExpired(ctx context.Context) ([]*models.Job, error) {
+	var jobs []*models.Job
+	if err := d.db.WithContext(ctx).
+		Where("status = ? AND locked_until < NOW()", models.JobStatusRunning).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list expired jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ListByStatus returns jobs in the given status, newest first
+func (d *JobDB) ListByStatus(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	var jobs []*models.Job
+	if err := d.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return jobs, nil
+}
@@ -72,7 +72,7 @@ func (d *StudentDB) GetStudentByID(studentID string) (*models.Student, error) {
 // GetStudentWeeklyStats retrieves all weekly stats for a student
 func (d *StudentDB) GetStudentWeeklyStats(studentID string) ([]models.WeeklyStats, error) {
 	var stats []models.WeeklyStats
-	if err := d.db.Where("student_id = ?", studentID).Order("week_start_date DESC").Find(&stats).Error; err != nil {
+	if err := d.db.Where("student_id = ?", studentID).Order("week_start DESC").Find(&stats).Error; err != nil {
 		return nil, err
 	}
 	return stats, nil
@@ -81,7 +81,7 @@ func (d *StudentDB) GetStudentWeeklyStats(studentID string) ([]models.WeeklyStat
 // GetWeeklyStats retrieves weekly stats for a student within a date range
 func (d *StudentDB) GetWeeklyStats(studentID string, start, end time.Time) (*models.WeeklyStats, error) {
 	var stats models.WeeklyStats
-	if err := d.db.Where("student_id = ? AND week_start_date = ?", studentID, start).First(&stats).Error; err != nil {
+	if err := d.db.Where("student_id = ? AND week_start = ?", studentID, start).First(&stats).Error; err != nil {
 		return nil, err
 	}
 	return &stats, nil
@@ -101,11 +101,11 @@ func (d *StudentDB) CreateWeeklyStats(stats *models.WeeklyStats) error {
 func (d *StudentDB) GetAllStudentsLatestStats() ([]models.WeeklyStats, error) {
 	var stats []models.WeeklyStats
 	subQuery := d.db.Model(&models.WeeklyStats{}).
-		Select("student_id, MAX(week_start_date) as max_date").
+		Select("student_id, MAX(week_start) as max_date").
 		Group("student_id")
 
 	if err := d.db.Table("weekly_stats").
-		Joins("JOIN (?) as latest ON weekly_stats.student_id = latest.student_id AND weekly_stats.week_start_date = latest.max_date",
+		Joins("JOIN (?) as latest ON weekly_stats.student_id = latest.student_id AND weekly_stats.week_start = latest.max_date",
 			subQuery).
 		Find(&stats).Error; err != nil {
 		return nil, err
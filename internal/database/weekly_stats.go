@@ -31,7 +31,7 @@ func NewWeeklyStatsDB(db *gorm.DB) WeeklyStatsDB {
 func (db *weeklyStatsDB) GetStudentWeeklyStats(studentID string) ([]models.WeeklyStats, error) {
 	var stats []models.WeeklyStats
 	err := db.db.Where("student_id = ?", studentID).
-		Order("week_start_date DESC").
+		Order("week_start DESC").
 		Find(&stats).Error
 	return stats, err
 }
@@ -39,7 +39,7 @@ func (db *weeklyStatsDB) GetStudentWeeklyStats(studentID string) ([]models.Weekl
 // GetWeeklyStats retrieves weekly stats for a specific week
 func (db *weeklyStatsDB) GetWeeklyStats(studentID string, start, end time.Time) (*models.WeeklyStats, error) {
 	var stats models.WeeklyStats
-	err := db.db.Where("student_id = ? AND week_start_date = ?", studentID, start).
+	err := db.db.Where("student_id = ? AND week_start = ?", studentID, start).
 		First(&stats).Error
 	if err != nil {
 		return nil, err
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ayush/ORBIT/internal/calendar"
 	"github.com/ayush/ORBIT/internal/leetcode"
 	"github.com/ayush/ORBIT/internal/models"
 	"github.com/ayush/ORBIT/internal/repository"
@@ -14,6 +15,7 @@ import (
 
 type ContestHistoryUpdater struct {
 	repo           *repository.StudentRepository
+	calendar       *calendar.Calendar
 	logger         *zap.Logger
 	ticker         *time.Ticker
 	updateInterval time.Duration
@@ -22,9 +24,10 @@ type ContestHistoryUpdater struct {
 	wg             sync.WaitGroup
 }
 
-func NewContestHistoryUpdater(repo *repository.StudentRepository, logger *zap.Logger, updateInterval time.Duration) *ContestHistoryUpdater {
+func NewContestHistoryUpdater(repo *repository.StudentRepository, cal *calendar.Calendar, logger *zap.Logger, updateInterval time.Duration) *ContestHistoryUpdater {
 	return &ContestHistoryUpdater{
 		repo:           repo,
+		calendar:       cal,
 		logger:         logger,
 		updateInterval: updateInterval,
 		done:           make(chan bool),
@@ -33,29 +36,10 @@ func NewContestHistoryUpdater(repo *repository.StudentRepository, logger *zap.Lo
 }
 
 // calculateNextWeeklyUpdate returns the duration until the next weekly update
-// Updates are scheduled for Sunday at 00:00 UTC
+// Updates are scheduled for the start of each institution week
 func (u *ContestHistoryUpdater) calculateNextWeeklyUpdate() time.Duration {
-	now := time.Now().UTC()
-	nextSunday := now
-
-	// Find the next Sunday
-	daysUntilSunday := time.Sunday - now.Weekday()
-	if daysUntilSunday <= 0 {
-		daysUntilSunday += 7 // If today is Sunday, go to next Sunday
-	}
-
-	nextSunday = time.Date(
-		now.Year(),
-		now.Month(),
-		now.Day()+int(daysUntilSunday),
-		0, // Hour
-		0, // Minute
-		0, // Second
-		0, // Nanosecond
-		time.UTC,
-	)
-
-	return nextSunday.Sub(now)
+	now := u.calendar.Now()
+	return u.calendar.WeekEnd(now).Sub(now)
 }
 
 func (u *ContestHistoryUpdater) Start() {
@@ -159,16 +143,19 @@ func (u *ContestHistoryUpdater) updateStudentContestHistory(student *models.Stud
 	now := time.Now()
 	var histories []*models.ContestHistory
 
-	// Create new contest history entries
+	// Create new contest history entries for the contests the student entered
 	for _, contest := range contestStats.Data.UserContestRankingHistory {
+		if !contest.Attended {
+			continue
+		}
 		history := &models.ContestHistory{
 			StudentID:         student.ID,
 			ContestTitle:      contest.Contest.Title,
 			Rating:            contest.Rating,
 			Ranking:           contest.Ranking,
 			ProblemsSolved:    contest.ProblemsSolved,
-			FinishTimeSeconds: contest.FinishTimeInSeconds,
-			ContestDate:       now,
+			FinishTimeSeconds: int64(contest.FinishTimeInSeconds),
+			ContestDate:       time.Unix(contest.Contest.StartTime, 0).UTC(),
 			CreatedAt:         now,
 		}
 		histories = append(histories, history)
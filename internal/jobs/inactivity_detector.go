@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+)
+
+// InactivityDetectorConfig controls which students are flagged as at risk
+// and how often they are re-evaluated
+type InactivityDetectorConfig struct {
+	Window   time.Duration // how long without solves or rating changes before a student is flagged
+	Interval time.Duration // how often flags are re-evaluated
+}
+
+// DefaultInactivityDetectorConfig flags students after two quiet weeks and
+// re-evaluates every 6 hours
+func DefaultInactivityDetectorConfig() InactivityDetectorConfig {
+	return InactivityDetectorConfig{
+		Window:   14 * 24 * time.Hour,
+		Interval: 6 * time.Hour,
+	}
+}
+
+// InactivityDetector periodically flags disengaged students and resolves the
+// flags of students who became active again
+type InactivityDetector struct {
+	risks  *service.RiskService
+	logger *zap.Logger
+	config InactivityDetectorConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewInactivityDetector(risks *service.RiskService, logger *zap.Logger, config InactivityDetectorConfig) *InactivityDetector {
+	return &InactivityDetector{
+		risks:  risks,
+		logger: logger,
+		config: config,
+	}
+}
+
+func (d *InactivityDetector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.logger.Info("Inactivity detector started",
+		zap.Duration("window", d.config.Window),
+		zap.Duration("interval", d.config.Interval))
+
+	d.wg.Add(1)
+	go d.run(ctx)
+}
+
+func (d *InactivityDetector) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+	d.logger.Info("Inactivity detector stopped")
+}
+
+func (d *InactivityDetector) run(ctx context.Context) {
+	defer d.wg.Done()
+
+	// Detect once on startup so flags reflect the current window
+	d.detect(ctx)
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.detect(ctx)
+		}
+	}
+}
+
+func (d *InactivityDetector) detect(ctx context.Context) {
+	if _, err := d.risks.Detect(ctx, d.config.Window); err != nil && ctx.Err() == nil {
+		d.logger.Error("Failed to detect inactive students", zap.Error(err))
+	}
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/queue"
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+)
+
+// Job types handled by the persistent queue
+const (
+	TypeImportStudents = "students.import"
+	TypeSyncRating     = "students.sync_rating"
+	TypeSyncContests   = "students.sync_contests"
+	TypeRefreshStats   = "students.refresh_stats"
+
+	TypeBackfillWeeklyStats = "weekly_stats.backfill"
+)
+
+// ImportStudentsPayload identifies the upload to import
+type ImportStudentsPayload struct {
+	FileUploadID uint `json:"file_upload_id"`
+}
+
+// StudentPayload identifies the student a sync job applies to
+type StudentPayload struct {
+	StudentID uint `json:"student_id"`
+}
+
+// BackfillWeeklyStatsPayload is the range of weeks to recompute; each bound
+// selects the week containing it
+type BackfillWeeklyStatsPayload struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// RegisterQueueHandlers wires the job types above to their implementations.
+// Successful syncs ask the aggregator to recompute batch and department stats.
+func RegisterQueueHandlers(q *queue.Queue, students *service.StudentService, imports *service.ImportService, refresh *RefreshQueue, aggregator *StatsAggregator, weekly *service.WeeklyStatsService, logger *zap.Logger) {
+	q.Register(TypeImportStudents, func(ctx context.Context, job *models.Job) error {
+		var payload ImportStudentsPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+		return imports.ProcessUpload(ctx, payload.FileUploadID)
+	})
+
+	q.OnDeadLetter(TypeImportStudents, func(ctx context.Context, job *models.Job) {
+		var payload ImportStudentsPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			logger.Error("Failed to decode dead import job", zap.Error(err))
+			return
+		}
+		if err := imports.MarkFailed(ctx, payload.FileUploadID, *job.LastError); err != nil {
+			logger.Error("Failed to mark upload as failed",
+				zap.Uint("file_upload_id", payload.FileUploadID),
+				zap.Error(err))
+		}
+	})
+
+	q.Register(TypeSyncRating, func(ctx context.Context, job *models.Job) error {
+		var payload StudentPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+		if _, err := students.SyncStudentRating(ctx, payload.StudentID); err != nil {
+			return err
+		}
+		aggregator.Request()
+		return nil
+	})
+
+	q.Register(TypeSyncContests, func(ctx context.Context, job *models.Job) error {
+		var payload StudentPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+		if _, err := students.UpdateContestHistory(ctx, payload.StudentID); err != nil {
+			return err
+		}
+		aggregator.Request()
+		return nil
+	})
+
+	// On-demand refreshes are handed to the in-process refresh queue so they
+	// share its API budget
+	q.Register(TypeRefreshStats, func(ctx context.Context, job *models.Job) error {
+		var payload StudentPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+		refresh.RequestRefresh(payload.StudentID)
+		return nil
+	})
+
+	q.Register(TypeBackfillWeeklyStats, func(ctx context.Context, job *models.Job) error {
+		var payload BackfillWeeklyStatsPayload
+		if err := queue.DecodePayload(job, &payload); err != nil {
+			return err
+		}
+		_, err := weekly.Backfill(ctx, payload.From, payload.To)
+		return err
+	})
+}
+
+// QueuedRefreshRequester forwards on-demand refresh requests from the API
+// process to the worker through the job queue
+type QueuedRefreshRequester struct {
+	queue    *queue.Queue
+	cooldown time.Duration
+	logger   *zap.Logger
+
+	mu        sync.Mutex
+	requested map[uint]time.Time
+}
+
+// NewQueuedRefreshRequester creates a requester that sends at most one request
+// per student within the cooldown
+func NewQueuedRefreshRequester(q *queue.Queue, cooldown time.Duration, logger *zap.Logger) *QueuedRefreshRequester {
+	return &QueuedRefreshRequester{
+		queue:     q,
+		cooldown:  cooldown,
+		logger:    logger,
+		requested: make(map[uint]time.Time),
+	}
+}
+
+// RequestRefresh enqueues a refresh job unless one was sent recently
+func (r *QueuedRefreshRequester) RequestRefresh(studentID uint) {
+	now := time.Now()
+
+	r.mu.Lock()
+	if last, ok := r.requested[studentID]; ok && now.Sub(last) < r.cooldown {
+		r.mu.Unlock()
+		return
+	}
+	r.requested[studentID] = now
+	for id, last := range r.requested {
+		if now.Sub(last) >= r.cooldown {
+			delete(r.requested, id)
+		}
+	}
+	r.mu.Unlock()
+
+	if _, err := r.queue.Enqueue(context.Background(), TypeRefreshStats, StudentPayload{StudentID: studentID}); err != nil {
+		r.logger.Error("Failed to queue stats refresh",
+			zap.Uint("student_id", studentID),
+			zap.Error(err))
+	}
+}
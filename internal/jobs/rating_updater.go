@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ayush/ORBIT/internal/calendar"
 	"github.com/ayush/ORBIT/internal/models"
 	"github.com/ayush/ORBIT/internal/repository"
 	"go.uber.org/zap"
@@ -13,6 +14,7 @@ import (
 
 type RatingUpdater struct {
 	repo           *repository.StudentRepository
+	calendar       *calendar.Calendar
 	logger         *zap.Logger
 	ticker         *time.Ticker
 	updateInterval time.Duration
@@ -21,9 +23,10 @@ type RatingUpdater struct {
 	wg             sync.WaitGroup
 }
 
-func NewRatingUpdater(repo *repository.StudentRepository, logger *zap.Logger, updateInterval time.Duration) *RatingUpdater {
+func NewRatingUpdater(repo *repository.StudentRepository, cal *calendar.Calendar, logger *zap.Logger, updateInterval time.Duration) *RatingUpdater {
 	return &RatingUpdater{
 		repo:           repo,
+		calendar:       cal,
 		logger:         logger,
 		updateInterval: updateInterval,
 		done:           make(chan bool),
@@ -32,29 +35,10 @@ func NewRatingUpdater(repo *repository.StudentRepository, logger *zap.Logger, up
 }
 
 // calculateNextWeeklyUpdate returns the duration until the next weekly update
-// Updates are scheduled for Sunday at 00:00 UTC
+// Updates are scheduled for the start of each institution week
 func (r *RatingUpdater) calculateNextWeeklyUpdate() time.Duration {
-	now := time.Now().UTC()
-	nextSunday := now
-
-	// Find the next Sunday
-	daysUntilSunday := time.Sunday - now.Weekday()
-	if daysUntilSunday <= 0 {
-		daysUntilSunday += 7 // If today is Sunday, go to next Sunday
-	}
-
-	nextSunday = time.Date(
-		now.Year(),
-		now.Month(),
-		now.Day()+int(daysUntilSunday),
-		0, // Hour
-		0, // Minute
-		0, // Second
-		0, // Nanosecond
-		time.UTC,
-	)
-
-	return nextSunday.Sub(now)
+	now := r.calendar.Now()
+	return r.calendar.WeekEnd(now).Sub(now)
 }
 
 func (r *RatingUpdater) Start() {
@@ -0,0 +1,334 @@
+package jobs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// RefreshQueueConfig controls how often students are refreshed and how many
+// LeetCode lookups the queue may spend
+type RefreshQueueConfig struct {
+	ActiveInterval   time.Duration // cadence for students who solved recently
+	DormantInterval  time.Duration // cadence for everyone else
+	ActiveWindow     time.Duration // how recent a solve must be to count as active
+	BudgetPerHour    int           // maximum refreshes per hour
+	ReloadInterval   time.Duration // how often candidates are re-read from the database
+	RetryDelay       time.Duration // wait before retrying a failed refresh
+	OnDemandCooldown time.Duration // ignore on-demand requests for freshly refreshed students
+}
+
+// DefaultRefreshQueueConfig refreshes active students daily and dormant ones weekly
+func DefaultRefreshQueueConfig() RefreshQueueConfig {
+	return RefreshQueueConfig{
+		ActiveInterval:   24 * time.Hour,
+		DormantInterval:  7 * 24 * time.Hour,
+		ActiveWindow:     7 * 24 * time.Hour,
+		BudgetPerHour:    600,
+		ReloadInterval:   15 * time.Minute,
+		RetryDelay:       time.Hour,
+		OnDemandCooldown: 15 * time.Minute,
+	}
+}
+
+// RefreshQueue refreshes LeetCode stats in priority order. On-demand requests
+// go first, then students ordered by when they fall due, where the due time is
+// the last refresh plus the active or dormant interval.
+type RefreshQueue struct {
+	repo    *repository.StudentRepository
+	service *service.StudentService
+	logger  *zap.Logger
+	config  RefreshQueueConfig
+	limiter *rate.Limiter
+
+	onRefreshed []func(studentID uint)
+
+	mu       sync.Mutex
+	items    refreshHeap
+	index    map[uint]*refreshItem
+	inFlight uint
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type refreshItem struct {
+	studentID     uint
+	lastRefreshed time.Time
+	dueAt         time.Time
+	requested     bool
+	index         int
+}
+
+// refreshHeap orders on-demand requests first, then the earliest due time
+type refreshHeap []*refreshItem
+
+func (h refreshHeap) Len() int { return len(h) }
+
+func (h refreshHeap) Less(i, j int) bool {
+	if h[i].requested != h[j].requested {
+		return h[i].requested
+	}
+	return h[i].dueAt.Before(h[j].dueAt)
+}
+
+func (h refreshHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *refreshHeap) Push(x interface{}) {
+	item := x.(*refreshItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *refreshHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+func NewRefreshQueue(repo *repository.StudentRepository, service *service.StudentService, logger *zap.Logger, config RefreshQueueConfig) *RefreshQueue {
+	limit := rate.Inf
+	if config.BudgetPerHour > 0 {
+		limit = rate.Every(time.Hour / time.Duration(config.BudgetPerHour))
+	}
+
+	return &RefreshQueue{
+		repo:    repo,
+		service: service,
+		logger:  logger,
+		config:  config,
+		limiter: rate.NewLimiter(limit, 1),
+		index:   make(map[uint]*refreshItem),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// OnRefreshed adds a callback run after each successful refresh. It must be
+// called before Start.
+func (q *RefreshQueue) OnRefreshed(fn func(studentID uint)) {
+	q.onRefreshed = append(q.onRefreshed, fn)
+}
+
+func (q *RefreshQueue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	q.logger.Info("Refresh queue started",
+		zap.Duration("active_interval", q.config.ActiveInterval),
+		zap.Duration("dormant_interval", q.config.DormantInterval),
+		zap.Int("budget_per_hour", q.config.BudgetPerHour))
+
+	q.wg.Add(1)
+	go q.run(ctx)
+}
+
+func (q *RefreshQueue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+	q.logger.Info("Refresh queue stopped")
+}
+
+// RequestRefresh moves a student to the front of the queue, typically because
+// someone is looking at their profile
+func (q *RefreshQueue) RequestRefresh(studentID uint) {
+	q.mu.Lock()
+	item, queued := q.index[studentID]
+	switch {
+	case queued && time.Since(item.lastRefreshed) < q.config.OnDemandCooldown:
+		q.mu.Unlock()
+		return
+	case queued:
+		item.requested = true
+		heap.Fix(&q.items, item.index)
+	case q.inFlight == studentID:
+		q.mu.Unlock()
+		return
+	default:
+		item = &refreshItem{studentID: studentID, requested: true}
+		q.index[studentID] = item
+		heap.Push(&q.items, item)
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *RefreshQueue) run(ctx context.Context) {
+	defer q.wg.Done()
+
+	q.reload(ctx)
+	reload := time.NewTicker(q.config.ReloadInterval)
+	defer reload.Stop()
+
+	for {
+		item, wait := q.next(time.Now())
+		if item != nil {
+			if err := q.limiter.Wait(ctx); err != nil {
+				return
+			}
+			q.refresh(ctx, item)
+
+			select {
+			case <-reload.C:
+				q.reload(ctx)
+			default:
+			}
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-reload.C:
+			q.reload(ctx)
+		case <-q.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// next pops the head of the queue if it is due, otherwise it reports how long
+// to wait before the head becomes due
+func (q *RefreshQueue) next(now time.Time) (*refreshItem, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.items.Len() == 0 {
+		return nil, q.config.ReloadInterval
+	}
+
+	head := q.items[0]
+	if !head.requested && head.dueAt.After(now) {
+		return nil, head.dueAt.Sub(now)
+	}
+
+	heap.Pop(&q.items)
+	delete(q.index, head.studentID)
+	q.inFlight = head.studentID
+	return head, 0
+}
+
+func (q *RefreshQueue) refresh(ctx context.Context, item *refreshItem) {
+	logger := q.logger.With(
+		zap.Uint("student_id", item.studentID),
+		zap.Bool("on_demand", item.requested),
+	)
+
+	stats, err := q.service.RefreshLeetCodeStats(ctx, item.studentID)
+	now := time.Now()
+
+	q.mu.Lock()
+	q.inFlight = 0
+
+	if err != nil {
+		logger.Error("Failed to refresh LeetCode stats", zap.Error(err))
+		q.schedule(item.studentID, item.lastRefreshed, now.Add(q.config.RetryDelay))
+		q.mu.Unlock()
+		return
+	}
+
+	logger.Info("Refreshed LeetCode stats", zap.Int("total_solved", stats.TotalSolved))
+	q.schedule(item.studentID, now, now.Add(q.intervalFor(&stats.LastSolvedAt, now)))
+	q.mu.Unlock()
+
+	for _, fn := range q.onRefreshed {
+		fn(item.studentID)
+	}
+}
+
+// schedule queues a student unless an on-demand request re-added them meanwhile.
+// Callers must hold q.mu.
+func (q *RefreshQueue) schedule(studentID uint, lastRefreshed, dueAt time.Time) {
+	if item, queued := q.index[studentID]; queued {
+		item.lastRefreshed = lastRefreshed
+		return
+	}
+	item := &refreshItem{studentID: studentID, lastRefreshed: lastRefreshed, dueAt: dueAt}
+	q.index[studentID] = item
+	heap.Push(&q.items, item)
+}
+
+// reload rebuilds the queue from the database so new students are picked up and
+// due times follow activity recorded elsewhere
+func (q *RefreshQueue) reload(ctx context.Context) {
+	candidates, err := q.repo.ListRefreshCandidates(ctx)
+	if err != nil {
+		q.logger.Error("Failed to load refresh candidates", zap.Error(err))
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rebuild(candidates, time.Now())
+
+	q.logger.Debug("Refresh queue reloaded", zap.Int("students", len(q.items)))
+}
+
+// rebuild replaces the queued students with the candidates, keeping pending
+// on-demand requests and retry backoffs. Callers must hold q.mu.
+func (q *RefreshQueue) rebuild(candidates []models.RefreshCandidate, now time.Time) {
+	items := make(refreshHeap, 0, len(candidates))
+	index := make(map[uint]*refreshItem, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.StudentID == q.inFlight {
+			continue
+		}
+		item := q.itemFor(candidate, now)
+		if existing, queued := q.index[candidate.StudentID]; queued {
+			item.requested = existing.requested
+			// Keep a retry backoff set after a failed refresh
+			if existing.dueAt.After(item.dueAt) {
+				item.dueAt = existing.dueAt
+			}
+		}
+		item.index = len(items)
+		items = append(items, item)
+		index[candidate.StudentID] = item
+	}
+	heap.Init(&items)
+
+	q.items = items
+	q.index = index
+}
+
+func (q *RefreshQueue) itemFor(candidate models.RefreshCandidate, now time.Time) *refreshItem {
+	item := &refreshItem{studentID: candidate.StudentID}
+	if candidate.LastRefreshedAt == nil {
+		// Never synced: due immediately
+		return item
+	}
+	item.lastRefreshed = *candidate.LastRefreshedAt
+	item.dueAt = item.lastRefreshed.Add(q.intervalFor(candidate.LastSolvedAt, now))
+	return item
+}
+
+func (q *RefreshQueue) intervalFor(lastSolvedAt *time.Time, now time.Time) time.Duration {
+	if lastSolvedAt != nil && now.Sub(*lastSolvedAt) <= q.config.ActiveWindow {
+		return q.config.ActiveInterval
+	}
+	return q.config.DormantInterval
+}
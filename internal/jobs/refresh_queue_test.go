@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"go.uber.org/zap"
+)
+
+func newTestRefreshQueue() *RefreshQueue {
+	return NewRefreshQueue(nil, nil, zap.NewNop(), DefaultRefreshQueueConfig())
+}
+
+func TestRefreshQueueOrder(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	longAgo := now.Add(-30 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		due       map[uint]time.Duration // due time of each scheduled student, relative to now
+		requested []uint
+		want      []uint
+	}{
+		{
+			name: "earliest due first",
+			due:  map[uint]time.Duration{1: -time.Hour, 2: -3 * time.Hour, 3: -2 * time.Hour},
+			want: []uint{2, 3, 1},
+		},
+		{
+			name:      "on-demand before due",
+			due:       map[uint]time.Duration{1: -3 * time.Hour, 2: time.Hour},
+			requested: []uint{2},
+			want:      []uint{2, 1},
+		},
+		{
+			name:      "unqueued on-demand student",
+			due:       map[uint]time.Duration{1: -time.Hour},
+			requested: []uint{9},
+			want:      []uint{9, 1},
+		},
+		{
+			name: "students not yet due wait",
+			due:  map[uint]time.Duration{1: -time.Hour, 2: time.Hour},
+			want: []uint{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestRefreshQueue()
+			q.mu.Lock()
+			for id, offset := range tt.due {
+				q.schedule(id, longAgo, now.Add(offset))
+			}
+			q.mu.Unlock()
+			for _, id := range tt.requested {
+				q.RequestRefresh(id)
+			}
+
+			var got []uint
+			for {
+				item, wait := q.next(now)
+				if item == nil {
+					if q.items.Len() > 0 && wait <= 0 {
+						t.Fatalf("next() waits %v with a student due", wait)
+					}
+					break
+				}
+				got = append(got, item.studentID)
+				q.inFlight = 0
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("refresh order = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("refresh order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshQueueOnDemandCooldown(t *testing.T) {
+	now := time.Now()
+	q := newTestRefreshQueue()
+	q.mu.Lock()
+	q.schedule(1, now, now.Add(time.Hour))
+	q.mu.Unlock()
+
+	q.RequestRefresh(1)
+	if item, _ := q.next(now); item != nil {
+		t.Fatalf("next() = student %d, want the request ignored during the cooldown", item.studentID)
+	}
+}
+
+func TestRefreshQueueItemFor(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	config := DefaultRefreshQueueConfig()
+	recent := now.Add(-24 * time.Hour)
+	dormant := now.Add(-30 * 24 * time.Hour)
+	refreshed := now.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name      string
+		candidate models.RefreshCandidate
+		wantDue   time.Time
+	}{
+		{
+			name:      "never synced",
+			candidate: models.RefreshCandidate{StudentID: 1},
+		},
+		{
+			name:      "active",
+			candidate: models.RefreshCandidate{StudentID: 1, LastRefreshedAt: &refreshed, LastSolvedAt: &recent},
+			wantDue:   refreshed.Add(config.ActiveInterval),
+		},
+		{
+			name:      "dormant",
+			candidate: models.RefreshCandidate{StudentID: 1, LastRefreshedAt: &refreshed, LastSolvedAt: &dormant},
+			wantDue:   refreshed.Add(config.DormantInterval),
+		},
+		{
+			name:      "never solved",
+			candidate: models.RefreshCandidate{StudentID: 1, LastRefreshedAt: &refreshed},
+			wantDue:   refreshed.Add(config.DormantInterval),
+		},
+	}
+
+	q := newTestRefreshQueue()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := q.itemFor(tt.candidate, now)
+			if !item.dueAt.Equal(tt.wantDue) {
+				t.Errorf("itemFor() due at %v, want %v", item.dueAt, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestRefreshQueueRebuildKeepsBackoff(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	config := DefaultRefreshQueueConfig()
+	refreshed := now.Add(-2 * config.DormantInterval)
+	candidates := []models.RefreshCandidate{
+		{StudentID: 1, LastRefreshedAt: &refreshed},
+		{StudentID: 2, LastRefreshedAt: &refreshed},
+	}
+
+	q := newTestRefreshQueue()
+	q.mu.Lock()
+	// Student 1 failed and waits out the retry delay; student 2 follows the
+	// recomputed interval
+	q.schedule(1, refreshed, now.Add(config.RetryDelay))
+	q.schedule(2, refreshed, refreshed)
+	q.rebuild(candidates, now)
+	q.mu.Unlock()
+
+	if due := q.index[1].dueAt; !due.Equal(now.Add(config.RetryDelay)) {
+		t.Errorf("failed student due at %v, want the retry at %v", due, now.Add(config.RetryDelay))
+	}
+	if due := q.index[2].dueAt; !due.Equal(refreshed.Add(config.DormantInterval)) {
+		t.Errorf("student 2 due at %v, want %v", due, refreshed.Add(config.DormantInterval))
+	}
+	if item, _ := q.next(now); item == nil || item.studentID != 2 {
+		t.Fatalf("next() = %v, want student 2", item)
+	}
+	q.inFlight = 0
+	if item, _ := q.next(now); item != nil {
+		t.Fatalf("next() = student %d, want the failed student to wait", item.studentID)
+	}
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+)
+
+// StatsAggregatorConfig controls how often batch, department and system stats
+// are recomputed
+type StatsAggregatorConfig struct {
+	Delay    time.Duration // wait after a sync so a burst of syncs is aggregated once
+	Interval time.Duration // aggregate at least this often even without syncs
+}
+
+// DefaultStatsAggregatorConfig aggregates at most every 15 minutes while syncs
+// are running and every 6 hours otherwise
+func DefaultStatsAggregatorConfig() StatsAggregatorConfig {
+	return StatsAggregatorConfig{
+		Delay:    15 * time.Minute,
+		Interval: 6 * time.Hour,
+	}
+}
+
+// StatsAggregator recomputes aggregate stats after student data changes.
+// Syncs call Request; requests arriving while an aggregation is already
+// pending are folded into it.
+type StatsAggregator struct {
+	analytics *service.AnalyticsService
+	logger    *zap.Logger
+	config    StatsAggregatorConfig
+
+	requests chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewStatsAggregator(analytics *service.AnalyticsService, logger *zap.Logger, config StatsAggregatorConfig) *StatsAggregator {
+	return &StatsAggregator{
+		analytics: analytics,
+		logger:    logger,
+		config:    config,
+		requests:  make(chan struct{}, 1),
+	}
+}
+
+func (a *StatsAggregator) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	a.logger.Info("Stats aggregator started",
+		zap.Duration("delay", a.config.Delay),
+		zap.Duration("interval", a.config.Interval))
+
+	a.wg.Add(1)
+	go a.run(ctx)
+}
+
+func (a *StatsAggregator) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+	a.logger.Info("Stats aggregator stopped")
+}
+
+// Request schedules an aggregation after the configured delay
+func (a *StatsAggregator) Request() {
+	select {
+	case a.requests <- struct{}{}:
+	default:
+	}
+}
+
+func (a *StatsAggregator) run(ctx context.Context) {
+	defer a.wg.Done()
+
+	// Aggregate once on startup so the endpoints have data
+	a.aggregate(ctx)
+
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.requests:
+			if pending == nil {
+				pending = time.After(a.config.Delay)
+			}
+		case <-pending:
+			pending = nil
+			a.aggregate(ctx)
+		case <-ticker.C:
+			a.aggregate(ctx)
+		}
+	}
+}
+
+func (a *StatsAggregator) aggregate(ctx context.Context) {
+	if err := a.analytics.Aggregate(ctx); err != nil && ctx.Err() == nil {
+		a.logger.Error("Failed to aggregate stats", zap.Error(err))
+	}
+}
@@ -3,11 +3,17 @@ package leetcode
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrUserNotFound is returned when LeetCode has no user with the given username
+var ErrUserNotFound = errors.New("leetcode user not found")
+
 type Service struct {
 	client    *http.Client
 	rateLimit *time.Ticker
@@ -28,6 +34,9 @@ type UserProfile struct {
 			} `json:"submitStats"`
 		} `json:"matchedUser"`
 	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
 }
 
 type ContestRankingInfo struct {
@@ -40,7 +49,8 @@ type ContestRankingInfo struct {
 		} `json:"userContestRanking"`
 		UserContestRankingHistory []struct {
 			Contest struct {
-				Title string `json:"title"`
+				Title     string `json:"title"`
+				StartTime int64  `json:"startTime"` // Unix seconds
 			} `json:"contest"`
 			Rating              float64 `json:"rating"`
 			Ranking             int     `json:"ranking"`
@@ -108,7 +118,7 @@ func (s *Service) GetUserProfile(username string) (*UserProfile, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("leetcode user not found: %s", username)
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
@@ -124,6 +134,14 @@ func (s *Service) GetUserProfile(username string) (*UserProfile, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// GraphQL answers unknown users with 200 and an error entry
+	if len(profile.Errors) > 0 {
+		if strings.Contains(strings.ToLower(profile.Errors[0].Message), "does not exist") {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+		}
+		return nil, fmt.Errorf("leetcode API error: %s", profile.Errors[0].Message)
+	}
+
 	return &profile, nil
 }
 
@@ -217,3 +235,178 @@ func (s *Service) GetContestRanking(username string) (*ContestRankingInfo, error
 
 	return &contestInfo, nil
 }
+
+// GetSubmissionCalendar returns how many submissions a user made on each day
+// of the past year, keyed by YYYY-MM-DD. LeetCode buckets submissions by UTC
+// day and counts every submission, accepted or not.
+func (s *Service) GetSubmissionCalendar(username string) (map[string]int, error) {
+	var response struct {
+		Data struct {
+			MatchedUser *struct {
+				UserCalendar struct {
+					SubmissionCalendar string `json:"submissionCalendar"`
+				} `json:"userCalendar"`
+			} `json:"matchedUser"`
+		} `json:"data"`
+	}
+	err := s.graphql(`
+			query userProfileCalendar($username: String!) {
+				matchedUser(username: $username) {
+					userCalendar {
+						submissionCalendar
+					}
+				}
+			}
+		`, username, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Data.MatchedUser == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	// The calendar is a JSON object encoded as a string, mapping the Unix time
+	// of each UTC midnight to that day's submission count
+	var raw map[string]int
+	if err := json.Unmarshal([]byte(response.Data.MatchedUser.UserCalendar.SubmissionCalendar), &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode submission calendar: %w", err)
+	}
+	calendar := make(map[string]int, len(raw))
+	for key, count := range raw {
+		seconds, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid submission calendar day %q: %w", key, err)
+		}
+		calendar[time.Unix(seconds, 0).UTC().Format("2006-01-02")] += count
+	}
+	return calendar, nil
+}
+
+// TagCount is how many problems of one topic tag a user has solved
+type TagCount struct {
+	Name           string
+	Slug           string
+	Level          string
+	ProblemsSolved int
+}
+
+// GetTagProblemCounts returns how many problems a user has solved per topic
+// tag, with the level LeetCode groups each tag under
+func (s *Service) GetTagProblemCounts(username string) ([]TagCount, error) {
+	type tagCount struct {
+		TagName        string `json:"tagName"`
+		TagSlug        string `json:"tagSlug"`
+		ProblemsSolved int    `json:"problemsSolved"`
+	}
+	var response struct {
+		Data struct {
+			MatchedUser *struct {
+				TagProblemCounts struct {
+					Advanced     []tagCount `json:"advanced"`
+					Intermediate []tagCount `json:"intermediate"`
+					Fundamental  []tagCount `json:"fundamental"`
+				} `json:"tagProblemCounts"`
+			} `json:"matchedUser"`
+		} `json:"data"`
+	}
+	err := s.graphql(`
+			query skillStats($username: String!) {
+				matchedUser(username: $username) {
+					tagProblemCounts {
+						advanced {
+							tagName
+							tagSlug
+							problemsSolved
+						}
+						intermediate {
+							tagName
+							tagSlug
+							problemsSolved
+						}
+						fundamental {
+							tagName
+							tagSlug
+							problemsSolved
+						}
+					}
+				}
+			}
+		`, username, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Data.MatchedUser == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	counts := response.Data.MatchedUser.TagProblemCounts
+	levels := []struct {
+		level string
+		tags  []tagCount
+	}{
+		{"fundamental", counts.Fundamental},
+		{"intermediate", counts.Intermediate},
+		{"advanced", counts.Advanced},
+	}
+	var tags []TagCount
+	for _, level := range levels {
+		for _, tag := range level.tags {
+			tags = append(tags, TagCount{
+				Name:           tag.TagName,
+				Slug:           tag.TagSlug,
+				Level:          level.level,
+				ProblemsSolved: tag.ProblemsSolved,
+			})
+		}
+	}
+	return tags, nil
+}
+
+// graphql sends a query taking a username to LeetCode and decodes the
+// response into out
+func (s *Service) graphql(query, username string, out interface{}) error {
+	<-s.rateLimit.C
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"query": query,
+		"variables": map[string]interface{}{
+			"username": username,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://leetcode.com/graphql", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.114 Safari/537.36")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("leetcode API rate limit exceeded")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("leetcode API returned non-200 status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
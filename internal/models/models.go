@@ -82,6 +82,129 @@ type ContestStats struct {
 	TotalProblemsSolved  int     `json:"total_problems_solved"`
 }
 
+// StudentExportRow is one student with their latest LeetCode stats, current
+// rating and contest summary, as written by the roster export
+type StudentExportRow struct {
+	ID                 uint       `json:"id"`
+	StudentID          string     `json:"student_id"`
+	Name               string     `json:"name"`
+	Email              string     `json:"email"`
+	LeetcodeID         string     `json:"leetcode_id"`
+	PassingYear        int        `json:"passing_year"`
+	Batch              string     `json:"batch"`
+	Department         string     `json:"department"`
+	TotalSolved        int        `json:"total_solved"`
+	EasySolved         int        `json:"easy_solved"`
+	MediumSolved       int        `json:"medium_solved"`
+	HardSolved         int        `json:"hard_solved"`
+	GlobalRanking      int        `json:"global_ranking"`
+	ContestRating      float64    `json:"contest_rating"`
+	StatsUpdatedAt     *time.Time `json:"stats_updated_at"`
+	CurrentRating      *int       `json:"current_rating"`
+	RatingRecordedAt   *time.Time `json:"rating_recorded_at"`
+	ContestsAttended   int        `json:"contests_attended"`
+	BestContestRating  *float64   `json:"best_contest_rating"`
+	BestContestRanking *int       `json:"best_contest_ranking"`
+	LastContestAt      *time.Time `json:"last_contest_at"`
+}
+
+// WeeklyReportRow is one student's activity during a report week: problems
+// solved, contests entered and their rating before and after the week
+type WeeklyReportRow struct {
+	ID               uint   `json:"id"`
+	StudentID        string `json:"student_id"`
+	Name             string `json:"name"`
+	Batch            string `json:"batch"`
+	Department       string `json:"department"`
+	ProblemsSolved   int    `json:"problems_solved"`
+	EasySolved       int    `json:"easy_solved"`
+	MediumSolved     int    `json:"medium_solved"`
+	HardSolved       int    `json:"hard_solved"`
+	ContestsAttended int    `json:"contests_attended"`
+	RatingStart      *int   `json:"rating_start"`
+	RatingEnd        *int   `json:"rating_end"`
+}
+
+// CohortWeekStats summarises a cohort's students a number of weeks after
+// their enrolment. Ratings and counts are taken from each student's last
+// snapshot before the end of the week. Problems solved count from enrolment
+// and are nil when no student has a snapshot from before it.
+type CohortWeekStats struct {
+	Cohort                   string   `json:"cohort"`
+	Week                     int      `json:"week"`
+	Students                 int      `json:"students"`
+	MedianProblemsCount      float64  `json:"median_problems_count"`
+	MedianProblemsSolved     *float64 `json:"median_problems_solved"`
+	RatingP25                float64  `json:"rating_p25"`
+	RatingP50                float64  `json:"rating_p50"`
+	RatingP75                float64  `json:"rating_p75"`
+	RatingP90                float64  `json:"rating_p90"`
+	ContestParticipationRate float64  `json:"contest_participation_rate"` // share of students entering a contest that week
+	AverageContests          float64  `json:"average_contests"`
+}
+
+// ContestParticipation summarises how a group of students did in one contest
+type ContestParticipation struct {
+	ContestTitle  string    `json:"contest_title"`
+	ContestDate   time.Time `json:"contest_date"`
+	Participants  int       `json:"participants"`
+	AverageRating float64   `json:"average_rating"`
+	BestRanking   *int      `json:"best_ranking"`
+}
+
+// WeeklyReport is a rendered weekly digest kept for later retrieval
+type WeeklyReport struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Batch      string    `json:"batch"`
+	Department string    `json:"department"`
+	WeekStart  time.Time `json:"week_start"`
+	WeekEnd    time.Time `json:"week_end"`
+	HTML       string    `json:"-" gorm:"column:html"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Leaderboard metrics
+const (
+	LeaderboardMetricRating         = "rating"
+	LeaderboardMetricProblemsSolved = "problems_solved"
+	LeaderboardMetricRatingGain     = "rating_gain"
+	LeaderboardMetricContestRating  = "contest_rating"
+	LeaderboardMetricStreak         = "streak"
+	LeaderboardMetricLongestStreak  = "longest_streak"
+	LeaderboardMetricConsistency    = "consistency"
+)
+
+// LeaderboardEntry is a student's position on a leaderboard: their score for
+// the chosen metric and how much it changed over the period
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	ID         uint    `json:"id"`
+	StudentID  string  `json:"student_id"`
+	Name       string  `json:"name"`
+	Batch      string  `json:"batch"`
+	Department string  `json:"department"`
+	Score      float64 `json:"score"`
+	Delta      float64 `json:"delta"`
+}
+
+// StudentScore is a student's score for a leaderboard with the fields used
+// to place them on department and batch boards
+type StudentScore struct {
+	ID         uint    `json:"id"`
+	Batch      string  `json:"batch"`
+	Department string  `json:"department"`
+	Score      float64 `json:"score"`
+}
+
+// RefreshCandidate is a student considered by the stats refresh queue along
+// with the timestamps used to decide how soon they are due
+type RefreshCandidate struct {
+	StudentID       uint       `json:"student_id"`
+	LeetcodeID      string     `json:"leetcode_id"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at"`
+	LastSolvedAt    *time.Time `json:"last_solved_at"`
+}
+
 // DailyProgress represents a student's daily problem-solving progress
 type DailyProgress struct {
 	ID             uint      `json:"id" gorm:"primaryKey"`
@@ -92,11 +215,14 @@ type DailyProgress struct {
 	MediumSolved   int       `json:"medium_solved"`
 	HardSolved     int       `json:"hard_solved"`
 	TimeSpent      int       `json:"time_spent"` // in minutes
+	Submissions    int       `json:"submissions"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
-// WeeklyStats represents a student's weekly statistics
+// WeeklyStats represents a student's weekly statistics. The *Solved fields
+// count problems solved during the week; the *Count fields are the totals at
+// the end of it.
 type WeeklyStats struct {
 	ID               uint      `json:"id" gorm:"primaryKey"`
 	StudentID        uint      `json:"student_id"`
@@ -121,41 +247,181 @@ type WeeklyStats struct {
 
 // StudentStats represents aggregated statistics for a student
 type StudentStats struct {
-	StudentID            uint             `json:"student_id"`
-	TotalProblemsSolved  int              `json:"total_problems_solved"`
-	EasySolved           int              `json:"easy_solved"`
-	MediumSolved         int              `json:"medium_solved"`
-	HardSolved           int              `json:"hard_solved"`
-	ContestsParticipated int              `json:"contests_participated"`
-	AverageRating        float64          `json:"average_rating"`
-	HighestRating        int              `json:"highest_rating"`
-	CurrentRating        int              `json:"current_rating"`
-	ProblemsCount        int              `json:"problems_count"`
-	GlobalRank           int              `json:"global_rank"`
-	ContestRating        float64          `json:"contest_rating"`
-	LastActive           time.Time        `json:"last_active"`
-	WeeklyProgress       []WeeklyStats    `json:"weekly_progress"`
-	ContestHistory       []ContestHistory `json:"contest_history"`
+	StudentID            uint                `json:"student_id"`
+	TotalProblemsSolved  int                 `json:"total_problems_solved"`
+	EasySolved           int                 `json:"easy_solved"`
+	MediumSolved         int                 `json:"medium_solved"`
+	HardSolved           int                 `json:"hard_solved"`
+	ContestsParticipated int                 `json:"contests_participated"`
+	AverageRating        float64             `json:"average_rating"`
+	HighestRating        int                 `json:"highest_rating"`
+	CurrentRating        int                 `json:"current_rating"`
+	ProblemsCount        int                 `json:"problems_count"`
+	GlobalRank           int                 `json:"global_rank"`
+	ContestRating        float64             `json:"contest_rating"`
+	LastActive           time.Time           `json:"last_active"`
+	WeeklyProgress       []WeeklyStats       `json:"weekly_progress"`
+	ContestHistory       []ContestHistory    `json:"contest_history"`
+	Streak               *StudentStreak      `json:"streak,omitempty"`
+	Percentiles          *StudentPercentiles `json:"percentiles,omitempty"`
+}
+
+// MetricPercentiles is a student's value for a metric and the percentage of
+// their department, batch and the whole institution at or below it. All are
+// nil when the student has no value.
+type MetricPercentiles struct {
+	Value       *float64 `json:"value"`
+	Department  *float64 `json:"department"`
+	Batch       *float64 `json:"batch"`
+	Institution *float64 `json:"institution"`
+}
+
+// StudentPercentiles places a student's latest rating, total solved and
+// contest rating among their peers
+type StudentPercentiles struct {
+	Rating         MetricPercentiles `json:"rating"`
+	ProblemsSolved MetricPercentiles `json:"problems_solved"`
+	ContestRating  MetricPercentiles `json:"contest_rating"`
+}
+
+// DistributionBucket counts the students whose value falls in [From, To)
+type DistributionBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// StudentStreak summarises how regularly a student solves problems, derived
+// from their daily progress
+type StudentStreak struct {
+	StudentID        uint       `json:"student_id" gorm:"primaryKey"`
+	CurrentStreak    int        `json:"current_streak"`
+	LongestStreak    int        `json:"longest_streak"`
+	ActiveDays30     int        `json:"active_days_30" gorm:"column:active_days_30"`
+	ActiveDays90     int        `json:"active_days_90" gorm:"column:active_days_90"`
+	ConsistencyScore float64    `json:"consistency_score"`
+	LastActiveDate   *time.Time `json:"last_active_date"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// StudentTagStat is how many problems of one LeetCode topic tag a student
+// has solved. Level is LeetCode's grouping of the tag: fundamental,
+// intermediate or advanced.
+type StudentTagStat struct {
+	StudentID      uint      `json:"student_id" gorm:"primaryKey"`
+	TagSlug        string    `json:"tag_slug" gorm:"primaryKey"`
+	TagName        string    `json:"tag_name"`
+	Level          string    `json:"level"`
+	ProblemsSolved int       `json:"problems_solved"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // FileUpload represents a file upload record
 type FileUpload struct {
-	ID                uint      `json:"id" gorm:"primaryKey"`
-	FileName          string    `json:"file_name"`
-	OriginalName      string    `json:"original_name"`
-	FileType          string    `json:"file_type"`
-	FileSize          int64     `json:"file_size"`
-	StoragePath       string    `json:"storage_path"`
-	Status            string    `json:"status"`
-	ProcessedAt       time.Time `json:"processed_at"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
-	UploadedBy        *uint     `json:"uploaded_by"`
-	TotalRecords      int       `json:"total_records"`
-	SuccessRecords    int       `json:"success_records"`
-	SuccessfulRecords int       `json:"successful_records"` // Adding this field
-	FailedRecords     int       `json:"failed_records"`
-	ErrorDetails      *string   `json:"error_details"`
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	FileName          string     `json:"file_name"`
+	OriginalName      string     `json:"original_name"`
+	FileType          string     `json:"file_type"`
+	FileSize          int64      `json:"file_size"`
+	StoragePath       string     `json:"storage_path"`
+	Status            string     `json:"status"`
+	ProcessedAt       time.Time  `json:"processed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	UploadedBy        *uint      `json:"uploaded_by"`
+	TotalRecords      int        `json:"total_records"`
+	SuccessRecords    int        `json:"success_records"`
+	SuccessfulRecords int        `json:"successful_records"` // Adding this field
+	FailedRecords     int        `json:"failed_records"`
+	ErrorDetails      *string    `json:"error_details"`
+	ColumnMapping     *string    `json:"column_mapping" gorm:"type:jsonb"`
+	ImportMode        string     `json:"import_mode" gorm:"default:insert"`
+	CreatedRecords    int        `json:"created_records"`
+	UpdatedRecords    int        `json:"updated_records"`
+	UnchangedRecords  int        `json:"unchanged_records"`
+	VerifyPolicy      string     `json:"verify_policy" gorm:"default:off"`
+	FlaggedRecords    int        `json:"flagged_records"`
+	RolledBackAt      *time.Time `json:"rolled_back_at"`
+}
+
+// Import row outcomes
+const (
+	ImportRowCreated   = "created"
+	ImportRowUpdated   = "updated"
+	ImportRowUnchanged = "unchanged"
+	ImportRowFailed    = "failed"
+)
+
+// ImportRow records what happened to one data row of an upload
+type ImportRow struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	FileUploadID uint      `json:"file_upload_id" gorm:"index"`
+	RowNumber    int       `json:"row_number"`
+	StudentID    string    `json:"student_id"`
+	StudentRefID *uint     `json:"student_ref_id"`
+	Outcome      string    `json:"outcome"`
+	Error        *string   `json:"error,omitempty"`
+	Warning      *string   `json:"warning,omitempty"`
+	Previous     *string   `json:"previous,omitempty" gorm:"type:jsonb"` // StudentSnapshot before an update
+	Applied      *string   `json:"applied,omitempty" gorm:"type:jsonb"`  // StudentSnapshot written by a create or update
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// StudentSnapshot holds the importable fields of a student, used to restore
+// values overwritten by an import
+type StudentSnapshot struct {
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	LeetcodeID  string `json:"leetcode_id"`
+	PassingYear int    `json:"passing_year"`
+	Batch       string `json:"batch"`
+	Department  string `json:"department"`
+}
+
+// AuditLog records an administrative action
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	Actor      string    `json:"actor"`
+	Details    string    `json:"details" gorm:"type:jsonb"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Job statuses
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusDead      = "dead"
+)
+
+// Job represents a unit of background work in the persistent job queue
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type"`
+	Payload     string     `json:"payload" gorm:"type:jsonb"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   *string    `json:"last_error"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// GroupStats is the aggregate of one batch or department, or of every
+// student when the group is empty
+type GroupStats struct {
+	GroupKey            string  `json:"group_key"`
+	TotalStudents       int     `json:"total_students"`
+	ActiveStudents      int     `json:"active_students"`
+	AverageRating       float64 `json:"average_rating"`
+	HighestRating       int     `json:"highest_rating"`
+	TotalProblemsSolved int     `json:"total_problems_solved"`
 }
 
 // BatchStats represents statistics for a batch of students
@@ -198,6 +464,55 @@ type SystemStats struct {
 	UpdatedAt            time.Time `json:"updated_at"`
 }
 
+// At-risk reasons
+const (
+	RiskReasonNoRecentSync   = "no_recent_sync"
+	RiskReasonNoNewSolves    = "no_new_solves"
+	RiskReasonNoRatingChange = "no_rating_change"
+)
+
+// AtRiskFlag records one reason a student was flagged as disengaged. A flag
+// stays open until the detector no longer finds the reason, when it is
+// resolved rather than deleted so mentors can see past episodes.
+type AtRiskFlag struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	StudentID  uint       `json:"student_id"`
+	Reason     string     `json:"reason"`
+	Detail     string     `json:"detail"`
+	FlaggedAt  time.Time  `json:"flagged_at"`
+	CheckedAt  time.Time  `json:"checked_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ActivitySignal is the evidence of a student's recent activity the
+// inactivity detector judges them on. LastSyncedAt is when their LeetCode
+// stats were last refreshed. Deltas compare the latest rating snapshot with
+// the one at the start of the window and are nil without snapshots; weekly
+// solves sum the weekly stats inside the window.
+type ActivitySignal struct {
+	StudentID     uint       `json:"student_id"`
+	LastSolvedAt  *time.Time `json:"last_solved_at"`
+	LastSyncedAt  *time.Time `json:"last_synced_at"`
+	CurrentRating *int       `json:"current_rating"`
+	ProblemsDelta *int       `json:"problems_delta"`
+	RatingDelta   *int       `json:"rating_delta"`
+	WeeklySolved  int        `json:"weekly_solved"`
+}
+
+// AtRiskStudent is a flagged student with their open flags
+type AtRiskStudent struct {
+	ID         uint         `json:"id"`
+	StudentID  string       `json:"student_id"`
+	Name       string       `json:"name"`
+	Email      string       `json:"email"`
+	Batch      string       `json:"batch"`
+	Department string       `json:"department"`
+	FlaggedAt  time.Time    `json:"flagged_at"`
+	Flags      []AtRiskFlag `json:"flags"`
+}
+
 // CreateStudentRequest represents the request body for creating a new student
 type CreateStudentRequest struct {
 	StudentID   string `json:"student_id" binding:"required"`
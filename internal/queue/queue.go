@@ -0,0 +1,309 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/models"
+	"go.uber.org/zap"
+)
+
+// HandlerFunc processes a single job. Returning an error schedules a retry
+// until the job runs out of attempts.
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// DeadLetterFunc is called once a job has been moved to the dead-letter state
+type DeadLetterFunc func(ctx context.Context, job *models.Job)
+
+// Config controls polling, locking and retry behaviour of the queue
+type Config struct {
+	Workers           int
+	PollInterval      time.Duration
+	VisibilityTimeout time.Duration
+	MaxAttempts       int
+	RetryBackoff      time.Duration // doubled after every failed attempt
+	ReapInterval      time.Duration
+}
+
+// DefaultConfig returns sensible defaults for the queue
+func DefaultConfig() Config {
+	return Config{
+		Workers:           2,
+		PollInterval:      2 * time.Second,
+		VisibilityTimeout: 5 * time.Minute,
+		MaxAttempts:       5,
+		RetryBackoff:      30 * time.Second,
+		ReapInterval:      time.Minute,
+	}
+}
+
+// Queue is a durable job queue backed by the jobs table. Any process can
+// enqueue; only processes that register handlers and call Start consume.
+type Queue struct {
+	db     *database.JobDB
+	logger *zap.Logger
+	config Config
+
+	handlers    map[string]HandlerFunc
+	deadLetters map[string]DeadLetterFunc
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new queue
+func New(db *database.JobDB, logger *zap.Logger, config Config) *Queue {
+	return &Queue{
+		db:          db,
+		logger:      logger,
+		config:      config,
+		handlers:    make(map[string]HandlerFunc),
+		deadLetters: make(map[string]DeadLetterFunc),
+	}
+}
+
+// Register sets the handler for a job type. It must be called before Start.
+func (q *Queue) Register(jobType string, handler HandlerFunc) {
+	q.handlers[jobType] = handler
+}
+
+// OnDeadLetter sets a callback for jobs of the given type that exhaust their attempts
+func (q *Queue) OnDeadLetter(jobType string, fn DeadLetterFunc) {
+	q.deadLetters[jobType] = fn
+}
+
+// Enqueue stores a new job with a JSON-encoded payload
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobStatusPending,
+		MaxAttempts: q.config.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	if err := q.db.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	q.logger.Debug("Job enqueued",
+		zap.Uint("job_id", job.ID),
+		zap.String("type", jobType))
+
+	return job, nil
+}
+
+// DecodePayload unmarshals a job's payload into v
+func DecodePayload(job *models.Job, v interface{}) error {
+	if err := json.Unmarshal([]byte(job.Payload), v); err != nil {
+		return fmt.Errorf("failed to decode payload of job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// Start recovers jobs left behind by a crashed process and starts the workers and reaper
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	q.reap(ctx)
+
+	types := q.types()
+	q.logger.Info("Job queue started",
+		zap.Int("workers", q.config.Workers),
+		zap.Strings("types", types))
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx, types)
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.config.ReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.reap(ctx)
+			}
+		}
+	}()
+}
+
+// Stop waits for running jobs to finish and stops the workers
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+	q.logger.Info("Job queue stopped")
+}
+
+func (q *Queue) types() []string {
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+func (q *Queue) work(ctx context.Context, types []string) {
+	defer q.wg.Done()
+
+	if len(types) == 0 {
+		return
+	}
+
+	for {
+		job, err := q.db.Claim(ctx, types, q.config.VisibilityTimeout)
+		if err != nil {
+			q.logger.Error("Failed to claim job", zap.Error(err))
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(q.config.PollInterval):
+			}
+			continue
+		}
+
+		q.process(ctx, job)
+	}
+}
+
+func (q *Queue) process(ctx context.Context, job *models.Job) {
+	start := time.Now()
+	logger := q.logger.With(
+		zap.Uint("job_id", job.ID),
+		zap.String("type", job.Type),
+		zap.Int("attempt", job.Attempts),
+	)
+
+	// Keep extending the lock while the handler runs so long jobs are not reaped
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	go q.heartbeat(heartbeatCtx, job)
+
+	err := q.run(ctx, job)
+	stopHeartbeat()
+
+	// Record the outcome even if we are shutting down
+	recordCtx := context.Background()
+
+	if err == nil {
+		if err := q.db.Complete(recordCtx, job.ID, job.Attempts); err != nil {
+			if errors.Is(err, database.ErrLeaseLost) {
+				logger.Warn("Job finished after its lease was lost", zap.Error(err))
+				return
+			}
+			logger.Error("Failed to mark job completed", zap.Error(err))
+			return
+		}
+		logger.Info("Job completed", zap.Duration("duration", time.Since(start)))
+		return
+	}
+
+	q.fail(recordCtx, job, err.Error(), logger)
+}
+
+// run calls the job's handler, turning a panic into an error so the job is
+// retried or dead-lettered instead of crashing the worker
+func (q *Queue) run(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			q.logger.Error("Job handler panicked",
+				zap.Uint("job_id", job.ID),
+				zap.String("type", job.Type),
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return q.handlers[job.Type](ctx, job)
+}
+
+// fail schedules a retry with exponential backoff or dead-letters the job
+func (q *Queue) fail(ctx context.Context, job *models.Job, reason string, logger *zap.Logger) {
+	if job.Attempts >= job.MaxAttempts {
+		if err := q.db.Bury(ctx, job.ID, job.Attempts, reason); err != nil {
+			if errors.Is(err, database.ErrLeaseLost) {
+				logger.Warn("Job failed after its lease was lost", zap.Error(err))
+				return
+			}
+			logger.Error("Failed to dead-letter job", zap.Error(err))
+			return
+		}
+		logger.Error("Job moved to dead letter", zap.String("error", reason))
+
+		job.Status = models.JobStatusDead
+		job.LastError = &reason
+		if fn, ok := q.deadLetters[job.Type]; ok {
+			fn(ctx, job)
+		}
+		return
+	}
+
+	delay := q.config.RetryBackoff << (job.Attempts - 1)
+	if err := q.db.Retry(ctx, job.ID, job.Attempts, time.Now().Add(delay), reason); err != nil {
+		if errors.Is(err, database.ErrLeaseLost) {
+			logger.Warn("Job failed after its lease was lost", zap.Error(err))
+			return
+		}
+		logger.Error("Failed to schedule job retry", zap.Error(err))
+		return
+	}
+	logger.Warn("Job failed, retry scheduled",
+		zap.String("error", reason),
+		zap.Duration("retry_in", delay))
+}
+
+func (q *Queue) heartbeat(ctx context.Context, job *models.Job) {
+	ticker := time.NewTicker(q.config.VisibilityTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := q.db.ExtendLock(ctx, job.ID, job.Attempts, q.config.VisibilityTimeout); err != nil {
+				q.logger.Warn("Failed to extend job lock",
+					zap.Uint("job_id", job.ID),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// reap returns jobs whose visibility timeout expired to the queue, or
+// dead-letters them if that was their last attempt
+func (q *Queue) reap(ctx context.Context) {
+	jobs, err := q.db.ListExpired(ctx)
+	if err != nil {
+		q.logger.Error("Failed to reap expired jobs", zap.Error(err))
+		return
+	}
+
+	for _, job := range jobs {
+		logger := q.logger.With(
+			zap.Uint("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Int("attempt", job.Attempts),
+		)
+		logger.Warn("Recovering job with expired lock")
+		q.fail(ctx, job, "visibility timeout expired", logger)
+	}
+}
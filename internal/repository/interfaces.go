@@ -37,6 +37,5 @@ type StudentDB interface {
 	UpdateLeetCodeStats(ctx context.Context, id uint, stats *models.LeetCodeStats) error
 	GetDailyProgress(ctx context.Context, studentID uint, start, end time.Time) ([]*models.DailyProgress, error)
 	GetWeeklyStats(ctx context.Context, studentID uint, start, end time.Time) (*models.WeeklyStats, error)
-	GetLeaderboard(ctx context.Context, start time.Time, department, batch string) ([]*models.Student, error)
-	GetTrendingStudents(ctx context.Context, start time.Time, limit int) ([]*models.Student, error)
+	GetLeaderboard(ctx context.Context, query LeaderboardQuery) ([]models.LeaderboardEntry, int64, error)
 }
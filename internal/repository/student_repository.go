@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ayush/ORBIT/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StudentRepository struct {
@@ -22,6 +26,11 @@ func (r *StudentRepository) Create(ctx context.Context, student *models.Student)
 	return r.DB.WithContext(ctx).Create(student).Error
 }
 
+// CreateInBatches inserts students in multi-row statements, filling in their IDs
+func (r *StudentRepository) CreateInBatches(ctx context.Context, students []*models.Student, batchSize int) error {
+	return r.DB.WithContext(ctx).CreateInBatches(students, batchSize).Error
+}
+
 func (r *StudentRepository) GetByID(ctx context.Context, id uint) (*models.Student, error) {
 	var student models.Student
 	if err := r.DB.WithContext(ctx).First(&student, id).Error; err != nil {
@@ -30,6 +39,16 @@ func (r *StudentRepository) GetByID(ctx context.Context, id uint) (*models.Stude
 	return &student, nil
 }
 
+// LockByID fetches a student and locks their row until the surrounding
+// transaction ends
+func (r *StudentRepository) LockByID(ctx context.Context, id uint) (*models.Student, error) {
+	var student models.Student
+	if err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&student, id).Error; err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
 func (r *StudentRepository) GetByLeetcodeID(ctx context.Context, leetcodeID string) (*models.Student, error) {
 	var student models.Student
 	if err := r.DB.WithContext(ctx).Where("leetcode_id = ?", leetcodeID).First(&student).Error; err != nil {
@@ -38,10 +57,57 @@ func (r *StudentRepository) GetByLeetcodeID(ctx context.Context, leetcodeID stri
 	return &student, nil
 }
 
+func (r *StudentRepository) GetByStudentID(ctx context.Context, studentID string) (*models.Student, error) {
+	var student models.Student
+	if err := r.DB.WithContext(ctx).Where("student_id = ?", studentID).First(&student).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &student, nil
+}
+
+// ListByIDs returns the students with the given primary keys
+func (r *StudentRepository) ListByIDs(ctx context.Context, ids []uint) ([]models.Student, error) {
+	var students []models.Student
+	if len(ids) == 0 {
+		return students, nil
+	}
+	if err := r.DB.WithContext(ctx).Where("id IN ?", ids).Find(&students).Error; err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+// ListByStudentIDs returns the students with the given college IDs
+func (r *StudentRepository) ListByStudentIDs(ctx context.Context, studentIDs []string) ([]models.Student, error) {
+	var students []models.Student
+	if len(studentIDs) == 0 {
+		return students, nil
+	}
+	if err := r.DB.WithContext(ctx).Where("student_id IN ?", studentIDs).Find(&students).Error; err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
 func (r *StudentRepository) Update(ctx context.Context, student *models.Student) error {
 	return r.DB.WithContext(ctx).Save(student).Error
 }
 
+// RestoreSnapshot writes previously saved field values back onto a student
+func (r *StudentRepository) RestoreSnapshot(ctx context.Context, id uint, snapshot models.StudentSnapshot) error {
+	return r.DB.WithContext(ctx).Model(&models.Student{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":         snapshot.Name,
+		"email":        snapshot.Email,
+		"leetcode_id":  snapshot.LeetcodeID,
+		"passing_year": snapshot.PassingYear,
+		"batch":        snapshot.Batch,
+		"department":   snapshot.Department,
+	}).Error
+}
+
 func (r *StudentRepository) Delete(ctx context.Context, id uint) error {
 	return r.DB.WithContext(ctx).Delete(&models.Student{}, id).Error
 }
@@ -69,14 +135,100 @@ func (r *StudentRepository) AddRating(ctx context.Context, rating *models.Rating
 	return r.DB.WithContext(ctx).Create(rating).Error
 }
 
+// GetBaselineRating returns the last rating recorded at or before start, or the
+// first one recorded between start and end when there is none before
+func (r *StudentRepository) GetBaselineRating(ctx context.Context, studentID uint, start, end time.Time) (*models.Rating, error) {
+	var rating models.Rating
+	err := r.DB.WithContext(ctx).
+		Where("student_id = ? AND recorded_at <= ?", studentID, end).
+		Order(clause.Expr{SQL: "recorded_at <= ? DESC, CASE WHEN recorded_at <= ? THEN recorded_at END DESC, recorded_at", Vars: []interface{}{start, start}}).
+		First(&rating).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rating, nil
+}
+
+// ListLatestRatings returns every student's most recent rating with the
+// batch and department used to scope leaderboards
+func (r *StudentRepository) ListLatestRatings(ctx context.Context) ([]models.StudentScore, error) {
+	var scores []models.StudentScore
+	err := r.DB.WithContext(ctx).
+		Raw("SELECT DISTINCT ON (r.student_id) s.id, s.batch, s.department, r.rating AS score " +
+			"FROM ratings r JOIN students s ON s.id = r.student_id " +
+			"ORDER BY r.student_id, r.recorded_at DESC").
+		Scan(&scores).Error
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
 func (r *StudentRepository) UpdateStudentRating(ctx context.Context, studentID uint, rating *models.Rating) error {
 	rating.StudentID = studentID
 	return r.DB.WithContext(ctx).Create(rating).Error
 }
 
+// GetStudentStats summarises a student's latest rating snapshot, rating
+// history, contests and recent weekly progress
 func (r *StudentRepository) GetStudentStats(ctx context.Context, studentID uint) (*models.StudentStats, error) {
-	var stats models.StudentStats
-	// Implement stats collection logic here
+	stats := models.StudentStats{StudentID: studentID}
+
+	var latest models.Rating
+	err := r.DB.WithContext(ctx).
+		Where("student_id = ?", studentID).
+		Order("recorded_at DESC").
+		First(&latest).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	stats.CurrentRating = latest.Rating
+	stats.ProblemsCount = latest.ProblemsCount
+	stats.TotalProblemsSolved = latest.ProblemsCount
+	stats.EasySolved = latest.EasyCount
+	stats.MediumSolved = latest.MediumCount
+	stats.HardSolved = latest.HardCount
+	stats.GlobalRank = latest.GlobalRank
+	stats.LastActive = latest.RecordedAt
+
+	var ratings struct {
+		AverageRating float64
+		HighestRating int
+	}
+	if err := r.DB.WithContext(ctx).Model(&models.Rating{}).
+		Select("AVG(rating) AS average_rating, MAX(rating) AS highest_rating").
+		Where("student_id = ?", studentID).
+		Scan(&ratings).Error; err != nil {
+		return nil, err
+	}
+	stats.AverageRating = ratings.AverageRating
+	stats.HighestRating = ratings.HighestRating
+
+	if err := r.DB.WithContext(ctx).
+		Where("student_id = ?", studentID).
+		Order("contest_date DESC").
+		Find(&stats.ContestHistory).Error; err != nil {
+		return nil, err
+	}
+	stats.ContestsParticipated = len(stats.ContestHistory)
+	if len(stats.ContestHistory) > 0 {
+		stats.ContestRating = stats.ContestHistory[0].Rating
+	}
+
+	if err := r.DB.WithContext(ctx).
+		Where("student_id = ?", studentID).
+		Order("week_start DESC").
+		Limit(12).
+		Find(&stats.WeeklyProgress).Error; err != nil {
+		return nil, err
+	}
+
 	return &stats, nil
 }
 
@@ -98,6 +250,181 @@ func (r *StudentRepository) ListStudents(ctx context.Context, offset, limit int,
 	return students, nil
 }
 
+// ListExportRows returns up to limit students with an ID above afterID, in ID
+// order, joined with their latest stats, latest rating and contest summary
+func (r *StudentRepository) ListExportRows(ctx context.Context, afterID uint, limit int, department, batch string) ([]models.StudentExportRow, error) {
+	query := r.DB.WithContext(ctx).
+		Table("students s").
+		Select("s.id, s.student_id, s.name, s.email, s.leetcode_id, s.passing_year, s.batch, s.department, "+
+			"COALESCE(ls.total_solved, 0) AS total_solved, COALESCE(ls.easy_solved, 0) AS easy_solved, "+
+			"COALESCE(ls.medium_solved, 0) AS medium_solved, COALESCE(ls.hard_solved, 0) AS hard_solved, "+
+			"COALESCE(ls.global_ranking, 0) AS global_ranking, COALESCE(ls.contest_rating, 0) AS contest_rating, "+
+			"ls.updated_at AS stats_updated_at, r.rating AS current_rating, r.recorded_at AS rating_recorded_at, "+
+			"COALESCE(ch.contests_attended, 0) AS contests_attended, ch.best_contest_rating, "+
+			"ch.best_contest_ranking, ch.last_contest_at").
+		Joins("LEFT JOIN leetcode_stats ls ON ls.student_id = s.id").
+		Joins("LEFT JOIN LATERAL (SELECT rating, recorded_at FROM ratings "+
+			"WHERE ratings.student_id = s.id ORDER BY recorded_at DESC LIMIT 1) r ON true").
+		Joins("LEFT JOIN LATERAL (SELECT COUNT(*) AS contests_attended, MAX(rating) AS best_contest_rating, "+
+			"MIN(NULLIF(ranking, 0)) AS best_contest_ranking, MAX(contest_date) AS last_contest_at "+
+			"FROM contest_history WHERE contest_history.student_id = s.id) ch ON true").
+		Where("s.id > ?", afterID)
+
+	if department != "" {
+		query = query.Where("s.department = ?", department)
+	}
+	if batch != "" {
+		query = query.Where("s.batch = ?", batch)
+	}
+
+	var rows []models.StudentExportRow
+	if err := query.Order("s.id").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListContestHistoryForStudents returns the contests of several students,
+// grouped by student and newest first
+func (r *StudentRepository) ListContestHistoryForStudents(ctx context.Context, studentIDs []uint) ([]models.ContestHistory, error) {
+	var history []models.ContestHistory
+	if len(studentIDs) == 0 {
+		return history, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Where("student_id IN ?", studentIDs).
+		Order("student_id, contest_date DESC").
+		Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ListWeeklyStatsForStudents returns the weekly stats of several students,
+// grouped by student and newest week first
+func (r *StudentRepository) ListWeeklyStatsForStudents(ctx context.Context, studentIDs []uint) ([]models.WeeklyStats, error) {
+	var stats []models.WeeklyStats
+	if len(studentIDs) == 0 {
+		return stats, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Select("id, student_id, week_start, week_end, problems_solved, easy_solved, medium_solved, "+
+			"hard_solved, contests_attended, average_rating").
+		Where("student_id IN ?", studentIDs).
+		Order("student_id, week_start DESC").
+		Find(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListWeeklyReportRows returns every matching student with their activity
+// between start and end: problems from the weekly stats row of that week,
+// contests from contest history and their latest rating before each bound
+func (r *StudentRepository) ListWeeklyReportRows(ctx context.Context, department, batch string, start, end time.Time) ([]models.WeeklyReportRow, error) {
+	query := r.DB.WithContext(ctx).
+		Table("students s").
+		Select("s.id, s.student_id, s.name, s.batch, s.department, "+
+			"COALESCE(ws.problems_solved, 0) AS problems_solved, COALESCE(ws.easy_solved, 0) AS easy_solved, "+
+			"COALESCE(ws.medium_solved, 0) AS medium_solved, COALESCE(ws.hard_solved, 0) AS hard_solved, "+
+			"COALESCE(ch.contests_attended, 0) AS contests_attended, "+
+			"rs.rating AS rating_start, re.rating AS rating_end").
+		Joins("LEFT JOIN weekly_stats ws ON ws.student_id = s.id AND ws.week_start = ?", start).
+		Joins("LEFT JOIN LATERAL (SELECT COUNT(*) AS contests_attended FROM contest_history "+
+			"WHERE contest_history.student_id = s.id AND contest_date >= ? AND contest_date < ?) ch ON true", start, end).
+		Joins("LEFT JOIN LATERAL (SELECT rating FROM ratings "+
+			"WHERE ratings.student_id = s.id AND recorded_at < ? ORDER BY recorded_at DESC LIMIT 1) rs ON true", start).
+		Joins("LEFT JOIN LATERAL (SELECT rating FROM ratings "+
+			"WHERE ratings.student_id = s.id AND recorded_at < ? ORDER BY recorded_at DESC LIMIT 1) re ON true", end)
+
+	if department != "" {
+		query = query.Where("s.department = ?", department)
+	}
+	if batch != "" {
+		query = query.Where("s.batch = ?", batch)
+	}
+
+	var rows []models.WeeklyReportRow
+	if err := query.Order("s.name, s.id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListContestParticipation summarises the contests held between start and end
+// that matching students took part in, oldest first
+func (r *StudentRepository) ListContestParticipation(ctx context.Context, department, batch string, start, end time.Time) ([]models.ContestParticipation, error) {
+	query := r.DB.WithContext(ctx).
+		Table("contest_history ch").
+		Select("COALESCE(ch.contest_title, ch.contest_name) AS contest_title, MIN(ch.contest_date) AS contest_date, "+
+			"COUNT(DISTINCT ch.student_id) AS participants, AVG(ch.rating) AS average_rating, "+
+			"MIN(NULLIF(ch.ranking, 0)) AS best_ranking").
+		Joins("JOIN students s ON s.id = ch.student_id").
+		Where("ch.contest_date >= ? AND ch.contest_date < ?", start, end)
+
+	if department != "" {
+		query = query.Where("s.department = ?", department)
+	}
+	if batch != "" {
+		query = query.Where("s.batch = ?", batch)
+	}
+
+	var contests []models.ContestParticipation
+	err := query.
+		Group("COALESCE(ch.contest_title, ch.contest_name)").
+		Order("contest_date").
+		Scan(&contests).Error
+	if err != nil {
+		return nil, err
+	}
+	return contests, nil
+}
+
+// AggregateStats totals students per value of groupBy ("batch" or
+// "department"), or across every student when groupBy is empty. Ratings are
+// each student's latest snapshot; a student is active if they solved a
+// problem since activeSince.
+func (r *StudentRepository) AggregateStats(ctx context.Context, groupBy string, activeSince time.Time) ([]models.GroupStats, error) {
+	groupKey := "''"
+	switch groupBy {
+	case "":
+	case "batch", "department":
+		groupKey = "s." + groupBy
+	default:
+		return nil, fmt.Errorf("unsupported stats grouping %q", groupBy)
+	}
+
+	query := r.DB.WithContext(ctx).
+		Table("students s").
+		Select(groupKey+" AS group_key, COUNT(*) AS total_students, "+
+			"COUNT(*) FILTER (WHERE ls.last_solved_at >= ?) AS active_students, "+
+			"COALESCE(AVG(r.rating), 0) AS average_rating, COALESCE(MAX(r.rating), 0) AS highest_rating, "+
+			"COALESCE(SUM(ls.total_solved), 0) AS total_problems_solved", activeSince).
+		Joins("LEFT JOIN leetcode_stats ls ON ls.student_id = s.id").
+		Joins("LEFT JOIN LATERAL (SELECT rating FROM ratings " +
+			"WHERE ratings.student_id = s.id ORDER BY recorded_at DESC LIMIT 1) r ON true")
+	if groupBy != "" {
+		query = query.Group(groupKey).Order(groupKey)
+	}
+
+	var stats []models.GroupStats
+	if err := query.Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// CountContests returns the number of distinct contests any student entered
+func (r *StudentRepository) CountContests(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).
+		Raw("SELECT COUNT(DISTINCT COALESCE(contest_title, contest_name)) FROM contest_history").
+		Scan(&count).Error
+	return count, err
+}
+
 func (r *StudentRepository) GetContestHistory(ctx context.Context, studentID uint) ([]models.ContestHistory, error) {
 	var history []models.ContestHistory
 	if err := r.DB.WithContext(ctx).Where("student_id = ?", studentID).Order("contest_date DESC").Find(&history).Error; err != nil {
@@ -134,6 +461,64 @@ func (r *StudentRepository) UpdateLeetCodeStats(ctx context.Context, id uint, st
 	return r.DB.WithContext(ctx).Where("id = ?", id).Updates(stats).Error
 }
 
+func (r *StudentRepository) GetLeetCodeStatsByStudentID(ctx context.Context, studentID uint) (*models.LeetCodeStats, error) {
+	var stats models.LeetCodeStats
+	if err := r.DB.WithContext(ctx).Where("student_id = ?", studentID).First(&stats).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// UpsertLeetCodeStats stores the latest stats snapshot, replacing the student's existing row
+func (r *StudentRepository) UpsertLeetCodeStats(ctx context.Context, stats *models.LeetCodeStats) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}},
+		UpdateAll: true,
+	}).Create(stats).Error
+}
+
+// ListRefreshCandidates returns every student with a LeetCode ID together with
+// the time their stats were last refreshed and last showed a new solve
+func (r *StudentRepository) ListRefreshCandidates(ctx context.Context) ([]models.RefreshCandidate, error) {
+	var candidates []models.RefreshCandidate
+	err := r.DB.WithContext(ctx).
+		Table("students").
+		Select("students.id AS student_id, students.leetcode_id, " +
+			"leetcode_stats.updated_at AS last_refreshed_at, leetcode_stats.last_solved_at").
+		Joins("LEFT JOIN leetcode_stats ON leetcode_stats.student_id = students.id").
+		Where("students.leetcode_id <> ''").
+		Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// FindByIdentifiers returns the students whose student ID, email or LeetCode ID
+// is among the given values
+func (r *StudentRepository) FindByIdentifiers(ctx context.Context, studentIDs, emails, leetcodeIDs []string) ([]models.Student, error) {
+	var students []models.Student
+	err := r.DB.WithContext(ctx).
+		Select("id", "student_id", "email", "leetcode_id").
+		Where("student_id IN ? OR email IN ? OR leetcode_id IN ?", nonEmpty(studentIDs), nonEmpty(emails), nonEmpty(leetcodeIDs)).
+		Find(&students).Error
+	if err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+// nonEmpty keeps IN clauses valid when a list is empty
+func nonEmpty(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
 func (r *StudentRepository) GetDailyProgress(ctx context.Context, studentID uint, start, end time.Time) ([]*models.DailyProgress, error) {
 	var progress []*models.DailyProgress
 	if err := r.DB.WithContext(ctx).
@@ -145,10 +530,164 @@ func (r *StudentRepository) GetDailyProgress(ctx context.Context, studentID uint
 	return progress, nil
 }
 
+// dailyProgressColumns are the daily_progress columns derived from snapshots;
+// time_spent is left alone since snapshots do not record it
+var dailyProgressColumns = []string{
+	"problems_solved", "easy_solved", "medium_solved", "hard_solved", "updated_at",
+}
+
+// ComputeDailyProgress stores the problems a student solved on date, the
+// institution's day [start, end), as the difference between the last rating
+// snapshot at or before start (or the first one that day for students first
+// synced on it) and the last one before end. The difference is only credited
+// when that baseline was taken within the previous day; after a longer gap
+// between syncs the solves cannot be placed on a day, so zeros are stored and
+// the gap is left to weekly stats and the submission calendar.
+func (r *StudentRepository) ComputeDailyProgress(ctx context.Context, studentID uint, date string, start, end time.Time) error {
+	args := map[string]interface{}{
+		"student_id": studentID,
+		"date":       date,
+		"start":      start,
+		"end":        end,
+		"prev":       start.AddDate(0, 0, -1),
+	}
+	sql := "INSERT INTO daily_progress (student_id, date, problems_solved, easy_solved, medium_solved, hard_solved, created_at, updated_at) " +
+		"SELECT @student_id, CAST(@date AS date), " +
+		"CASE WHEN base.recorded_at >= @prev THEN GREATEST(cur.problems_count - base.problems_count, 0) ELSE 0 END, " +
+		"CASE WHEN base.recorded_at >= @prev THEN GREATEST(cur.easy_count - base.easy_count, 0) ELSE 0 END, " +
+		"CASE WHEN base.recorded_at >= @prev THEN GREATEST(cur.medium_count - base.medium_count, 0) ELSE 0 END, " +
+		"CASE WHEN base.recorded_at >= @prev THEN GREATEST(cur.hard_count - base.hard_count, 0) ELSE 0 END, NOW(), NOW() " +
+		"FROM (SELECT problems_count, easy_count, medium_count, hard_count FROM ratings " +
+		"WHERE student_id = @student_id AND recorded_at >= @start AND recorded_at < @end " +
+		"ORDER BY recorded_at DESC LIMIT 1) cur " +
+		"CROSS JOIN (SELECT recorded_at, problems_count, easy_count, medium_count, hard_count FROM ratings " +
+		"WHERE student_id = @student_id AND recorded_at < @end " +
+		"ORDER BY recorded_at <= @start DESC, CASE WHEN recorded_at <= @start THEN recorded_at END DESC, recorded_at " +
+		"LIMIT 1) base " +
+		"ON CONFLICT (student_id, date) DO UPDATE SET " +
+		"problems_solved = EXCLUDED.problems_solved, easy_solved = EXCLUDED.easy_solved, " +
+		"medium_solved = EXCLUDED.medium_solved, hard_solved = EXCLUDED.hard_solved, updated_at = EXCLUDED.updated_at"
+	return r.DB.WithContext(ctx).Exec(sql, args).Error
+}
+
+// UpsertDailySubmissions stores a student's submission count for each date,
+// keyed YYYY-MM-DD, leaving the snapshot-derived columns of existing rows
+// alone
+func (r *StudentRepository) UpsertDailySubmissions(ctx context.Context, studentID uint, submissions map[string]int) error {
+	if len(submissions) == 0 {
+		return nil
+	}
+	rows := make([]models.DailyProgress, 0, len(submissions))
+	for date, count := range submissions {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return fmt.Errorf("invalid date %q: %w", date, err)
+		}
+		rows = append(rows, models.DailyProgress{StudentID: studentID, Date: day, Submissions: count})
+	}
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"submissions", "updated_at"}),
+	}).CreateInBatches(rows, 500).Error
+}
+
+// ListActiveDates returns the dates, formatted YYYY-MM-DD, on which each
+// student solved a problem or made a submission, in order. studentIDs limits
+// the students when given.
+func (r *StudentRepository) ListActiveDates(ctx context.Context, studentIDs []uint) (map[uint][]string, error) {
+	query := r.DB.WithContext(ctx).Model(&models.DailyProgress{}).
+		Select("student_id, TO_CHAR(date, 'YYYY-MM-DD') AS date").
+		Where("(problems_solved > 0 OR submissions > 0)")
+	if len(studentIDs) > 0 {
+		query = query.Where("student_id IN ?", studentIDs)
+	}
+
+	var rows []struct {
+		StudentID uint
+		Date      string
+	}
+	if err := query.Order("student_id, date").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	dates := make(map[uint][]string)
+	for _, row := range rows {
+		dates[row.StudentID] = append(dates[row.StudentID], row.Date)
+	}
+	return dates, nil
+}
+
+// ListStudentIDs returns the ID of every student
+func (r *StudentRepository) ListStudentIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	if err := r.DB.WithContext(ctx).Model(&models.Student{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *StudentRepository) GetStudentStreak(ctx context.Context, studentID uint) (*models.StudentStreak, error) {
+	var streak models.StudentStreak
+	if err := r.DB.WithContext(ctx).Where("student_id = ?", studentID).First(&streak).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &streak, nil
+}
+
+// UpsertStudentStreaks stores recalculated streaks, replacing existing rows
+func (r *StudentRepository) UpsertStudentStreaks(ctx context.Context, streaks []models.StudentStreak) error {
+	if len(streaks) == 0 {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}},
+		UpdateAll: true,
+	}).CreateInBatches(streaks, 500).Error
+}
+
+// ReplaceTagStats replaces a student's per-tag solve counts, so tags that
+// dropped out of their profile are removed
+func (r *StudentRepository) ReplaceTagStats(ctx context.Context, studentID uint, stats []models.StudentTagStat) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("student_id = ?", studentID).Delete(&models.StudentTagStat{}).Error; err != nil {
+			return err
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(stats, 500).Error
+	})
+}
+
+// ListTagStats returns the per-tag solve counts of several students, most
+// solved first
+func (r *StudentRepository) ListTagStats(ctx context.Context, studentIDs []uint) (map[uint][]models.StudentTagStat, error) {
+	var stats []models.StudentTagStat
+	if len(studentIDs) > 0 {
+		err := r.DB.WithContext(ctx).
+			Where("student_id IN ?", studentIDs).
+			Order("student_id, problems_solved DESC, tag_slug").
+			Find(&stats).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byStudent := make(map[uint][]models.StudentTagStat, len(studentIDs))
+	for _, stat := range stats {
+		byStudent[stat.StudentID] = append(byStudent[stat.StudentID], stat)
+	}
+	return byStudent, nil
+}
+
 func (r *StudentRepository) GetWeeklyStats(ctx context.Context, studentID uint, start, end time.Time) (*models.WeeklyStats, error) {
 	var stats models.WeeklyStats
 	if err := r.DB.WithContext(ctx).
-		Where("student_id = ? AND week_start_date BETWEEN ? AND ?", studentID, start, end).
+		Where("student_id = ? AND week_start >= ? AND week_start < ?", studentID, start, end).
+		Order("week_start DESC").
 		First(&stats).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, ErrNotFound
@@ -158,40 +697,598 @@ func (r *StudentRepository) GetWeeklyStats(ctx context.Context, studentID uint,
 	return &stats, nil
 }
 
-func (r *StudentRepository) GetLeaderboard(ctx context.Context, start time.Time, department, batch string) ([]*models.Student, error) {
-	var students []*models.Student
-	query := r.DB.WithContext(ctx).
-		Joins("LEFT JOIN weekly_stats ON students.id = weekly_stats.student_id").
-		Where("weekly_stats.week_start_date >= ?", start)
+// weeklyStatsColumns are the weekly_stats columns derived from snapshots;
+// time_spent is left alone since snapshots do not record it
+var weeklyStatsColumns = []string{
+	"week_end", "problems_solved", "easy_solved", "medium_solved", "hard_solved",
+	"contests_attended", "average_rating", "contest_rating", "global_ranking",
+	"problems_count", "easy_count", "medium_count", "hard_count", "updated_at",
+}
+
+// ComputeWeeklyStats derives the stats of the week [start, end) from rating
+// snapshots and contest history. Solved counts are the difference between the
+// last snapshot at or before start (or the first one in the week for students
+// first synced that week) and the last one before end. Only students with a
+// snapshot before end are returned; studentIDs limits the students when given.
+func (r *StudentRepository) ComputeWeeklyStats(ctx context.Context, start, end time.Time, studentIDs []uint) ([]models.WeeklyStats, error) {
+	args := map[string]interface{}{
+		"start": start,
+		"end":   end,
+	}
+	filters := ""
+	if len(studentIDs) > 0 {
+		filters = " WHERE s.id IN @ids"
+		args["ids"] = studentIDs
+	}
 
+	sql := "SELECT s.id AS student_id, CAST(@start AS timestamptz) AS week_start, CAST(@end AS timestamptz) AS week_end, " +
+		"GREATEST(cur.problems_count - base.problems_count, 0) AS problems_solved, " +
+		"GREATEST(cur.easy_count - base.easy_count, 0) AS easy_solved, " +
+		"GREATEST(cur.medium_count - base.medium_count, 0) AS medium_solved, " +
+		"GREATEST(cur.hard_count - base.hard_count, 0) AS hard_solved, " +
+		"COALESCE(contests.attended, 0) AS contests_attended, " +
+		"COALESCE(week.average_rating, cur.rating) AS average_rating, " +
+		"COALESCE(ccur.rating, 0) AS contest_rating, " +
+		"cur.global_rank AS global_ranking, " +
+		"cur.problems_count, cur.easy_count, cur.medium_count, cur.hard_count " +
+		"FROM students s " +
+		"JOIN LATERAL (SELECT rating, problems_count, easy_count, medium_count, hard_count, global_rank FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at < @end ORDER BY recorded_at DESC LIMIT 1) cur ON true " +
+		"JOIN LATERAL (SELECT problems_count, easy_count, medium_count, hard_count FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at < @end " +
+		"ORDER BY recorded_at <= @start DESC, CASE WHEN recorded_at <= @start THEN recorded_at END DESC, recorded_at " +
+		"LIMIT 1) base ON true " +
+		"LEFT JOIN LATERAL (SELECT AVG(rating) AS average_rating FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at >= @start AND recorded_at < @end) week ON true " +
+		"LEFT JOIN LATERAL (SELECT COUNT(*) AS attended FROM contest_history " +
+		"WHERE contest_history.student_id = s.id AND contest_date >= @start AND contest_date < @end) contests ON true " +
+		"LEFT JOIN LATERAL (SELECT rating FROM contest_history " +
+		"WHERE contest_history.student_id = s.id AND contest_date < @end ORDER BY contest_date DESC LIMIT 1) ccur ON true" +
+		filters +
+		" ORDER BY s.id"
+
+	var stats []models.WeeklyStats
+	if err := r.DB.WithContext(ctx).Raw(sql, args).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// UpsertWeeklyStats stores computed weekly stats, replacing the derived
+// columns of rows that already exist for the student and week
+func (r *StudentRepository) UpsertWeeklyStats(ctx context.Context, stats []models.WeeklyStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}, {Name: "week_start"}},
+		DoUpdates: clause.AssignmentColumns(weeklyStatsColumns),
+	}).CreateInBatches(stats, 500).Error
+}
+
+// LeaderboardQuery selects the metric, period and students of a leaderboard
+type LeaderboardQuery struct {
+	Metric     string
+	Start      time.Time // scores change from the last snapshot at or before Start
+	End        time.Time
+	PrevStart  time.Time // start of the preceding period, used for rating gain deltas
+	Department string
+	Batch      string
+	Offset     int
+	Limit      int
+}
+
+// leaderboardMetric is the SQL that scores students for one metric. Score and
+// delta may refer to the cur, base and prev rating snapshots or the ccur and
+// cbase contest snapshots joined in by the listed laterals.
+type leaderboardMetric struct {
+	joins []string
+	score string
+	delta string
+}
+
+const (
+	// Latest rating snapshot in the period
+	currentRatingJoin = "LEFT JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at <= @end ORDER BY recorded_at DESC LIMIT 1) cur ON true"
+	// Last snapshot before the period, or the first one inside it for students
+	// who started being tracked during the period
+	baseRatingJoin = "LEFT JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at <= @end " +
+		"ORDER BY recorded_at <= @start DESC, CASE WHEN recorded_at <= @start THEN recorded_at END DESC, recorded_at " +
+		"LIMIT 1) base ON true"
+	prevRatingJoin = "LEFT JOIN LATERAL (SELECT rating FROM ratings " +
+		"WHERE ratings.student_id = s.id AND recorded_at <= @prev_start ORDER BY recorded_at DESC LIMIT 1) prev ON true"
+	currentContestJoin = "LEFT JOIN LATERAL (SELECT rating FROM contest_history " +
+		"WHERE contest_history.student_id = s.id AND contest_date <= @end ORDER BY contest_date DESC LIMIT 1) ccur ON true"
+	baseContestJoin = "LEFT JOIN LATERAL (SELECT rating FROM contest_history " +
+		"WHERE contest_history.student_id = s.id AND contest_date <= @end " +
+		"ORDER BY contest_date <= @start DESC, CASE WHEN contest_date <= @start THEN contest_date END DESC, contest_date " +
+		"LIMIT 1) cbase ON true"
+	streakJoin = "LEFT JOIN student_streaks st ON st.student_id = s.id"
+)
+
+var leaderboardMetrics = map[string]leaderboardMetric{
+	models.LeaderboardMetricRating: {
+		joins: []string{currentRatingJoin, baseRatingJoin},
+		score: "cur.rating",
+		delta: "cur.rating - base.rating",
+	},
+	models.LeaderboardMetricProblemsSolved: {
+		joins: []string{currentRatingJoin, baseRatingJoin},
+		score: "cur.problems_count",
+		delta: "cur.problems_count - base.problems_count",
+	},
+	// Gain over the period; the delta compares it with the preceding period
+	models.LeaderboardMetricRatingGain: {
+		joins: []string{currentRatingJoin, baseRatingJoin, prevRatingJoin},
+		score: "cur.rating - base.rating",
+		delta: "(cur.rating - base.rating) - COALESCE(base.rating - prev.rating, 0)",
+	},
+	models.LeaderboardMetricContestRating: {
+		joins: []string{currentContestJoin, baseContestJoin},
+		score: "ccur.rating",
+		delta: "ccur.rating - cbase.rating",
+	},
+	// Streak metrics describe the present, so they ignore the period
+	models.LeaderboardMetricStreak: {
+		joins: []string{streakJoin},
+		score: "st.current_streak",
+		delta: "NULL",
+	},
+	models.LeaderboardMetricLongestStreak: {
+		joins: []string{streakJoin},
+		score: "st.longest_streak",
+		delta: "NULL",
+	},
+	models.LeaderboardMetricConsistency: {
+		joins: []string{streakJoin},
+		score: "st.consistency_score",
+		delta: "NULL",
+	},
+}
+
+// GetLeaderboard ranks students by a metric over a period. Students without
+// data for the metric are left out; ties share a rank. It also returns the
+// number of ranked students for pagination.
+func (r *StudentRepository) GetLeaderboard(ctx context.Context, query LeaderboardQuery) ([]models.LeaderboardEntry, int64, error) {
+	metric, ok := leaderboardMetrics[query.Metric]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported leaderboard metric %q", query.Metric)
+	}
+
+	args := map[string]interface{}{
+		"start":      query.Start,
+		"end":        query.End,
+		"prev_start": query.PrevStart,
+		"offset":     query.Offset,
+		"limit":      query.Limit,
+	}
+	filters := ""
+	if query.Department != "" {
+		filters += " AND s.department = @department"
+		args["department"] = query.Department
+	}
+	if query.Batch != "" {
+		filters += " AND s.batch = @batch"
+		args["batch"] = query.Batch
+	}
+
+	sql := "WITH scored AS (" +
+		"SELECT s.id, s.student_id, s.name, s.batch, s.department, " +
+		metric.score + " AS score, COALESCE(" + metric.delta + ", 0) AS delta " +
+		"FROM students s " + strings.Join(metric.joins, " ") +
+		" WHERE true" + filters +
+		") SELECT RANK() OVER (ORDER BY score DESC) AS rank, id, student_id, name, batch, department, " +
+		"score, delta, COUNT(*) OVER () AS total " +
+		"FROM scored WHERE score IS NOT NULL " +
+		"ORDER BY rank, name, id LIMIT @limit OFFSET @offset"
+
+	var rows []struct {
+		models.LeaderboardEntry
+		Total int64
+	}
+	if err := r.DB.WithContext(ctx).Raw(sql, args).Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]models.LeaderboardEntry, len(rows))
+	var total int64
+	for i, row := range rows {
+		entries[i] = row.LeaderboardEntry
+		total = row.Total
+	}
+	return entries, total, nil
+}
+
+// ListActivitySignals returns every student's activity evidence over the
+// window starting at since: their last recorded solve, when their LeetCode
+// stats were last refreshed, their latest rating snapshot and its change from
+// the last one at or before since (or the first one after it for students
+// first synced during the window), and the problems solved in weekly stats
+// for weeks starting inside the window
+func (r *StudentRepository) ListActivitySignals(ctx context.Context, since time.Time) ([]models.ActivitySignal, error) {
+	sql := "SELECT s.id AS student_id, ls.last_solved_at, ls.updated_at AS last_synced_at, cur.rating AS current_rating, " +
+		"cur.problems_count - base.problems_count AS problems_delta, cur.rating - base.rating AS rating_delta, " +
+		"COALESCE(weeks.solved, 0) AS weekly_solved " +
+		"FROM students s " +
+		"LEFT JOIN leetcode_stats ls ON ls.student_id = s.id " +
+		"LEFT JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+		"WHERE ratings.student_id = s.id ORDER BY recorded_at DESC LIMIT 1) cur ON true " +
+		"LEFT JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+		"WHERE ratings.student_id = s.id " +
+		"ORDER BY recorded_at <= @since DESC, CASE WHEN recorded_at <= @since THEN recorded_at END DESC, recorded_at " +
+		"LIMIT 1) base ON true " +
+		"LEFT JOIN LATERAL (SELECT SUM(problems_solved) AS solved FROM weekly_stats " +
+		"WHERE weekly_stats.student_id = s.id AND week_start >= @since) weeks ON true " +
+		"ORDER BY s.id"
+
+	var signals []models.ActivitySignal
+	if err := r.DB.WithContext(ctx).Raw(sql, map[string]interface{}{"since": since}).Scan(&signals).Error; err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
+
+// SyncRiskFlags makes flags the set of open at-risk flags: flags already open
+// for the same student and reason get the new detail, new ones are opened and
+// open flags missing from the set are resolved. It returns how many flags
+// were opened and resolved.
+func (r *StudentRepository) SyncRiskFlags(ctx context.Context, flags []models.AtRiskFlag, now time.Time) (int, int, error) {
+	type flagKey struct {
+		studentID uint
+		reason    string
+	}
+	opened, resolved := 0, 0
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var open []models.AtRiskFlag
+		if err := tx.Where("resolved_at IS NULL").Find(&open).Error; err != nil {
+			return err
+		}
+		existing := make(map[flagKey]models.AtRiskFlag, len(open))
+		for _, flag := range open {
+			existing[flagKey{flag.StudentID, flag.Reason}] = flag
+		}
+
+		var created []models.AtRiskFlag
+		for _, flag := range flags {
+			key := flagKey{flag.StudentID, flag.Reason}
+			current, ok := existing[key]
+			if !ok {
+				flag.FlaggedAt = now
+				flag.CheckedAt = now
+				created = append(created, flag)
+				continue
+			}
+			delete(existing, key)
+			if err := tx.Model(&models.AtRiskFlag{}).Where("id = ?", current.ID).Updates(map[string]interface{}{
+				"detail":     flag.Detail,
+				"checked_at": now,
+				"updated_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(created, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		var stale []uint
+		for _, flag := range existing {
+			stale = append(stale, flag.ID)
+		}
+		if len(stale) > 0 {
+			if err := tx.Model(&models.AtRiskFlag{}).Where("id IN ?", stale).Updates(map[string]interface{}{
+				"resolved_at": now,
+				"checked_at":  now,
+				"updated_at":  now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		opened, resolved = len(created), len(stale)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return opened, resolved, nil
+}
+
+// ListAtRiskStudents returns the students with open at-risk flags, optionally
+// limited to a department and batch, longest flagged first
+func (r *StudentRepository) ListAtRiskStudents(ctx context.Context, department, batch string) ([]models.AtRiskStudent, error) {
+	query := r.DB.WithContext(ctx).Model(&models.Student{}).
+		Where("id IN (SELECT student_id FROM at_risk_flags WHERE resolved_at IS NULL)")
 	if department != "" {
-		query = query.Where("students.department = ?", department)
+		query = query.Where("department = ?", department)
 	}
 	if batch != "" {
-		query = query.Where("students.batch = ?", batch)
+		query = query.Where("batch = ?", batch)
 	}
 
-	err := query.
-		Order("weekly_stats.total_problems_solved DESC").
-		Group("students.id").
-		Find(&students).Error
-	if err != nil {
+	var students []models.Student
+	if err := query.Find(&students).Error; err != nil {
 		return nil, err
 	}
-	return students, nil
+	if len(students) == 0 {
+		return []models.AtRiskStudent{}, nil
+	}
+
+	ids := make([]uint, len(students))
+	for i, student := range students {
+		ids[i] = student.ID
+	}
+	var flags []models.AtRiskFlag
+	if err := r.DB.WithContext(ctx).
+		Where("student_id IN ? AND resolved_at IS NULL", ids).
+		Order("flagged_at, reason").
+		Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	byStudent := make(map[uint][]models.AtRiskFlag, len(students))
+	for _, flag := range flags {
+		byStudent[flag.StudentID] = append(byStudent[flag.StudentID], flag)
+	}
+
+	result := make([]models.AtRiskStudent, 0, len(students))
+	for _, student := range students {
+		studentFlags := byStudent[student.ID]
+		if len(studentFlags) == 0 {
+			continue
+		}
+		result = append(result, models.AtRiskStudent{
+			ID:         student.ID,
+			StudentID:  student.StudentID,
+			Name:       student.Name,
+			Email:      student.Email,
+			Batch:      student.Batch,
+			Department: student.Department,
+			FlaggedAt:  studentFlags[0].FlaggedAt,
+			Flags:      studentFlags,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FlaggedAt.Equal(result[j].FlaggedAt) {
+			return result[i].FlaggedAt.Before(result[j].FlaggedAt)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
 }
 
-func (r *StudentRepository) GetTrendingStudents(ctx context.Context, start time.Time, limit int) ([]*models.Student, error) {
-	var students []*models.Student
-	err := r.DB.WithContext(ctx).
-		Joins("LEFT JOIN weekly_stats ON students.id = weekly_stats.student_id").
-		Where("weekly_stats.week_start_date >= ?", start).
-		Order("weekly_stats.problems_solved_this_week DESC").
-		Limit(limit).
-		Group("students.id").
-		Find(&students).Error
-	if err != nil {
+// ListRatingHistory returns the rating snapshots recorded since the given time
+// for each of the students, oldest first
+func (r *StudentRepository) ListRatingHistory(ctx context.Context, studentIDs []uint, since time.Time) (map[uint][]models.Rating, error) {
+	history := make(map[uint][]models.Rating)
+	if len(studentIDs) == 0 {
+		return history, nil
+	}
+
+	var ratings []models.Rating
+	if err := r.DB.WithContext(ctx).
+		Where("student_id IN ? AND recorded_at >= ?", studentIDs, since).
+		Order("student_id, recorded_at").
+		Find(&ratings).Error; err != nil {
 		return nil, err
 	}
-	return students, nil
+	for _, rating := range ratings {
+		history[rating.StudentID] = append(history[rating.StudentID], rating)
+	}
+	return history, nil
+}
+
+// CohortQuery selects the students and weeks of a cohort comparison
+type CohortQuery struct {
+	GroupBy    string   // passing_year or batch
+	Cohorts    []string // limits the cohorts when given
+	Department string
+	Weeks      int // weeks after enrolment to report
+
+	// Enrolment is the start of the academic year ProgrammeYears before the
+	// passing year, in the institution's timezone
+	AcademicYearStart time.Month
+	ProgrammeYears    int
+	Timezone          string
+}
+
+// ListCohortWeekStats aligns students on their enrolment, derived from their
+// passing year, and summarises each cohort for every ended week since then.
+// Week n covers the seven days starting n weeks after enrolment; a student
+// counts in a week once they have a rating snapshot from before its end, so
+// weeks before stats were first synced have no data. Problems solved since
+// enrolment need a snapshot from before it and are NULL otherwise.
+func (r *StudentRepository) ListCohortWeekStats(ctx context.Context, query CohortQuery, now time.Time) ([]models.CohortWeekStats, error) {
+	// Students without a passing year have no enrolment, and students without
+	// the grouping field set belong to no cohort
+	var cohort, filters string
+	switch query.GroupBy {
+	case "passing_year":
+		cohort = "CAST(s.passing_year AS text)"
+	case "batch":
+		cohort = "s.batch"
+		filters = " AND s.batch <> ''"
+	default:
+		return nil, fmt.Errorf("unsupported cohort grouping %q", query.GroupBy)
+	}
+
+	args := map[string]interface{}{
+		"weeks":           query.Weeks,
+		"now":             now,
+		"start_month":     int(query.AcademicYearStart),
+		"programme_years": query.ProgrammeYears,
+		"timezone":        query.Timezone,
+	}
+	if len(query.Cohorts) > 0 {
+		filters += " AND " + cohort + " IN @cohorts"
+		args["cohorts"] = query.Cohorts
+	}
+	if query.Department != "" {
+		filters += " AND s.department = @department"
+		args["department"] = query.Department
+	}
+
+	sql := "WITH enrolled AS (" +
+		"SELECT s.id AS student_id, " + cohort + " AS cohort, " +
+		"make_timestamptz(s.passing_year - CAST(@programme_years AS int), CAST(@start_month AS int), 1, 0, 0, 0, CAST(@timezone AS text)) AS enrolled_at " +
+		"FROM students s WHERE s.passing_year > 0" + filters +
+		"), aligned AS (" +
+		"SELECT e.cohort, w.week, cur.rating, cur.problems_count, " +
+		"cur.problems_count - base.problems_count AS problems_solved, COALESCE(contests.entered, 0) AS contests " +
+		"FROM enrolled e " +
+		"CROSS JOIN LATERAL generate_series(0, @weeks - 1) AS w(week) " +
+		"JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+		"WHERE ratings.student_id = e.student_id AND recorded_at < e.enrolled_at + (w.week + 1) * INTERVAL '7 days' " +
+		"ORDER BY recorded_at DESC LIMIT 1) cur ON true " +
+		"LEFT JOIN LATERAL (SELECT problems_count FROM ratings " +
+		"WHERE ratings.student_id = e.student_id AND recorded_at <= e.enrolled_at " +
+		"ORDER BY recorded_at DESC LIMIT 1) base ON true " +
+		"LEFT JOIN LATERAL (SELECT COUNT(*) AS entered FROM contest_history " +
+		"WHERE contest_history.student_id = e.student_id " +
+		"AND contest_date >= e.enrolled_at + w.week * INTERVAL '7 days' " +
+		"AND contest_date < e.enrolled_at + (w.week + 1) * INTERVAL '7 days') contests ON true " +
+		"WHERE e.enrolled_at + (w.week + 1) * INTERVAL '7 days' <= @now" +
+		") SELECT cohort, week, COUNT(*) AS students, " +
+		"percentile_cont(0.5) WITHIN GROUP (ORDER BY problems_count) AS median_problems_count, " +
+		"percentile_cont(0.5) WITHIN GROUP (ORDER BY problems_solved) AS median_problems_solved, " +
+		"percentile_cont(0.25) WITHIN GROUP (ORDER BY rating) AS rating_p25, " +
+		"percentile_cont(0.5) WITHIN GROUP (ORDER BY rating) AS rating_p50, " +
+		"percentile_cont(0.75) WITHIN GROUP (ORDER BY rating) AS rating_p75, " +
+		"percentile_cont(0.9) WITHIN GROUP (ORDER BY rating) AS rating_p90, " +
+		"AVG(CASE WHEN contests > 0 THEN 1.0 ELSE 0.0 END) AS contest_participation_rate, " +
+		"AVG(contests) AS average_contests " +
+		"FROM aligned GROUP BY cohort, week ORDER BY cohort, week"
+
+	var stats []models.CohortWeekStats
+	if err := r.DB.WithContext(ctx).Raw(sql, args).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// latestMetricsSQL selects every student's latest rating and problem count
+// from rating snapshots and latest contest rating from contest history
+const latestMetricsSQL = "SELECT s.id, s.department, s.batch, cur.rating, cur.problems_count, ccur.rating AS contest_rating " +
+	"FROM students s " +
+	"LEFT JOIN LATERAL (SELECT rating, problems_count FROM ratings " +
+	"WHERE ratings.student_id = s.id ORDER BY recorded_at DESC LIMIT 1) cur ON true " +
+	"LEFT JOIN LATERAL (SELECT rating FROM contest_history " +
+	"WHERE contest_history.student_id = s.id ORDER BY contest_date DESC LIMIT 1) ccur ON true"
+
+// distributionColumns are the latestMetricsSQL columns of each distribution
+// and percentile metric
+var distributionColumns = map[string]string{
+	models.LeaderboardMetricRating:         "rating",
+	models.LeaderboardMetricProblemsSolved: "problems_count",
+	models.LeaderboardMetricContestRating:  "contest_rating",
+}
+
+// GetStudentPercentiles returns the cumulative distribution of a student's
+// latest rating, problem count and contest rating within their department,
+// their batch and all students, as fractions of each group at or below the
+// student. Students without a value are left out of the groups.
+func (r *StudentRepository) GetStudentPercentiles(ctx context.Context, studentID uint) (*models.StudentPercentiles, error) {
+	var columns []string
+	for _, column := range []string{"rating", "problems_count", "contest_rating"} {
+		for _, group := range []struct{ name, partition string }{
+			{"department", "department, "},
+			{"batch", "batch, "},
+			{"institution", ""},
+		} {
+			columns = append(columns, fmt.Sprintf(
+				"CASE WHEN %[1]s IS NOT NULL THEN cume_dist() OVER (PARTITION BY %[2]s%[1]s IS NULL ORDER BY %[1]s) END AS %[1]s_%[3]s",
+				column, group.partition, group.name))
+		}
+	}
+	sql := "WITH latest AS (" + latestMetricsSQL + ") " +
+		"SELECT * FROM (SELECT id, CAST(rating AS double precision) AS rating, " +
+		"CAST(problems_count AS double precision) AS problems_count, contest_rating, " +
+		strings.Join(columns, ", ") + " FROM latest) ranked WHERE id = @id"
+
+	var row struct {
+		ID                       uint
+		Rating                   *float64
+		ProblemsCount            *float64
+		ContestRating            *float64
+		RatingDepartment         *float64
+		RatingBatch              *float64
+		RatingInstitution        *float64
+		ProblemsCountDepartment  *float64
+		ProblemsCountBatch       *float64
+		ProblemsCountInstitution *float64
+		ContestRatingDepartment  *float64
+		ContestRatingBatch       *float64
+		ContestRatingInstitution *float64
+	}
+	result := r.DB.WithContext(ctx).Raw(sql, map[string]interface{}{"id": studentID}).Scan(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &models.StudentPercentiles{
+		Rating: models.MetricPercentiles{
+			Value:       row.Rating,
+			Department:  row.RatingDepartment,
+			Batch:       row.RatingBatch,
+			Institution: row.RatingInstitution,
+		},
+		ProblemsSolved: models.MetricPercentiles{
+			Value:       row.ProblemsCount,
+			Department:  row.ProblemsCountDepartment,
+			Batch:       row.ProblemsCountBatch,
+			Institution: row.ProblemsCountInstitution,
+		},
+		ContestRating: models.MetricPercentiles{
+			Value:       row.ContestRating,
+			Department:  row.ContestRatingDepartment,
+			Batch:       row.ContestRatingBatch,
+			Institution: row.ContestRatingInstitution,
+		},
+	}, nil
+}
+
+// GetDistribution counts students by bucket of the metric's latest value,
+// optionally limited to a department and batch. Buckets are identified by
+// floor(value / bucketSize); empty buckets are not returned.
+func (r *StudentRepository) GetDistribution(ctx context.Context, metric string, bucketSize float64, department, batch string) (map[int]int, error) {
+	column, ok := distributionColumns[metric]
+	if !ok {
+		return nil, fmt.Errorf("unsupported distribution metric %q", metric)
+	}
+
+	args := map[string]interface{}{"bucket": bucketSize}
+	filters := ""
+	if department != "" {
+		filters += " AND department = @department"
+		args["department"] = department
+	}
+	if batch != "" {
+		filters += " AND batch = @batch"
+		args["batch"] = batch
+	}
+
+	sql := "WITH latest AS (" + latestMetricsSQL + ") " +
+		"SELECT CAST(FLOOR(" + column + " / CAST(@bucket AS double precision)) AS integer) AS bucket, COUNT(*) AS count " +
+		"FROM latest WHERE " + column + " IS NOT NULL" + filters +
+		" GROUP BY 1 ORDER BY 1"
+
+	var rows []struct {
+		Bucket int
+		Count  int
+	}
+	if err := r.DB.WithContext(ctx).Raw(sql, args).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	return counts, nil
 }
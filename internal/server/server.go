@@ -11,7 +11,6 @@ import (
 	"github.com/ayush/ORBIT/internal/database"
 	"github.com/ayush/ORBIT/internal/middleware"
 	"github.com/ayush/ORBIT/internal/router"
-	"github.com/ayush/ORBIT/internal/worker"
 	"github.com/ayush/ORBIT/pkg/leetcode"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -20,34 +19,29 @@ import (
 
 // Server represents the HTTP server and its dependencies
 type Server struct {
-	config      *config.Config
-	logger      *zap.Logger
-	db          *gorm.DB
-	httpServer  *http.Server
-	router      *gin.Engine
-	statsWorker *worker.WeeklyStatsWorker
+	config     *config.Config
+	logger     *zap.Logger
+	db         *gorm.DB
+	httpServer *http.Server
+	router     *gin.Engine
 }
 
 // New creates a new server instance
 func New(cfg *config.Config, logger *zap.Logger) (*Server, error) {
 	// Initialize database
-	db, err := database.InitDB()
+	db, err := database.InitDB(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
 	// Initialize database handlers
 	studentDB := database.NewStudentDB(db)
-	weeklyStatsDB := database.NewWeeklyStatsDB(db)
 	leetcodeClient := leetcode.NewClient()
 
 	// Initialize handlers
 	studentHandler := handlers.NewHandler(studentDB, logger)
 	weeklyStatsHandler := handlers.NewWeeklyStatsHandler(studentDB, leetcodeClient, logger)
 
-	// Initialize weekly stats worker
-	statsWorker := worker.NewWeeklyStatsWorker(studentDB, weeklyStatsDB, leetcodeClient)
-
 	// Setup router using the router package
 	r := router.SetupRouter(studentHandler, weeklyStatsHandler)
 
@@ -55,11 +49,10 @@ func New(cfg *config.Config, logger *zap.Logger) (*Server, error) {
 	r.Use(middleware.RequestID())
 
 	server := &Server{
-		config:      cfg,
-		logger:      logger,
-		db:          db,
-		router:      r,
-		statsWorker: statsWorker,
+		config: cfg,
+		logger: logger,
+		db:     db,
+		router: r,
 		httpServer: &http.Server{
 			Addr:         fmt.Sprintf(":%s", cfg.ServerPort),
 			Handler:      r,
@@ -72,11 +65,8 @@ func New(cfg *config.Config, logger *zap.Logger) (*Server, error) {
 	return server, nil
 }
 
-// Start starts the HTTP server and background workers
+// Start starts the HTTP server. Background jobs run in cmd/worker.
 func (s *Server) Start() error {
-	// Start weekly stats worker
-	s.statsWorker.Start()
-
 	s.logger.Info("Starting server...", zap.String("port", s.config.ServerPort))
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)
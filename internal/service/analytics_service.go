@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// activeStudentWindow is how recently a student must have solved a problem to
+// count as active in aggregated stats
+const activeStudentWindow = 7 * 24 * time.Hour
+
+// BatchAnalytics is the current stats of a batch and how they changed over time
+type BatchAnalytics struct {
+	Batch   string              `json:"batch"`
+	Current *models.BatchStats  `json:"current"`
+	History []models.BatchStats `json:"history"`
+}
+
+// DepartmentAnalytics is the current stats of a department and how they
+// changed over time
+type DepartmentAnalytics struct {
+	Department string                   `json:"department"`
+	Current    *models.DepartmentStats  `json:"current"`
+	History    []models.DepartmentStats `json:"history"`
+}
+
+// AnalyticsService maintains batch, department and system-wide aggregates.
+// Every aggregation appends a new snapshot so trends can be charted.
+type AnalyticsService struct {
+	repo        *repository.StudentRepository
+	batches     *database.BatchStatsDB
+	departments *database.DepartmentStatsDB
+	system      *database.SystemStatsDB
+	logger      *zap.Logger
+}
+
+func NewAnalyticsService(students *StudentService, logger *zap.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:        students.repo,
+		batches:     database.NewBatchStatsDB(students.repo.DB),
+		departments: database.NewDepartmentStatsDB(students.repo.DB),
+		system:      database.NewSystemStatsDB(students.repo.DB),
+		logger:      logger,
+	}
+}
+
+// Aggregate recomputes the stats of every batch, every department and the
+// whole system from current student data and stores them as one snapshot
+func (s *AnalyticsService) Aggregate(ctx context.Context) error {
+	start := time.Now()
+	activeSince := start.Add(-activeStudentWindow)
+
+	byBatch, err := s.repo.AggregateStats(ctx, "batch", activeSince)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate batch stats: %w", err)
+	}
+	byDepartment, err := s.repo.AggregateStats(ctx, "department", activeSince)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate department stats: %w", err)
+	}
+	overall, err := s.repo.AggregateStats(ctx, "", activeSince)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate system stats: %w", err)
+	}
+	contests, err := s.repo.CountContests(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count contests: %w", err)
+	}
+
+	err = s.repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		batches := database.NewBatchStatsDB(tx)
+		departments := database.NewDepartmentStatsDB(tx)
+		system := database.NewSystemStatsDB(tx)
+
+		for _, group := range byBatch {
+			if group.GroupKey == "" {
+				continue
+			}
+			if err := batches.Create(ctx, &models.BatchStats{
+				Batch:               group.GroupKey,
+				TotalStudents:       group.TotalStudents,
+				ActiveStudents:      group.ActiveStudents,
+				AverageRating:       group.AverageRating,
+				HighestRating:       group.HighestRating,
+				TotalProblemsSolved: group.TotalProblemsSolved,
+			}); err != nil {
+				return fmt.Errorf("failed to save stats for batch %s: %w", group.GroupKey, err)
+			}
+		}
+
+		for _, group := range byDepartment {
+			if group.GroupKey == "" {
+				continue
+			}
+			if err := departments.Create(ctx, &models.DepartmentStats{
+				Department:          group.GroupKey,
+				TotalStudents:       group.TotalStudents,
+				ActiveStudents:      group.ActiveStudents,
+				AverageRating:       group.AverageRating,
+				HighestRating:       group.HighestRating,
+				TotalProblemsSolved: group.TotalProblemsSolved,
+			}); err != nil {
+				return fmt.Errorf("failed to save stats for department %s: %w", group.GroupKey, err)
+			}
+		}
+
+		snapshot := &models.SystemStats{TotalContests: int(contests), LastUpdated: start}
+		if len(overall) > 0 {
+			snapshot.TotalStudents = overall[0].TotalStudents
+			snapshot.ActiveStudents = overall[0].ActiveStudents
+			snapshot.TotalProblemsSolved = overall[0].TotalProblemsSolved
+			snapshot.AverageStudentRating = overall[0].AverageRating
+			snapshot.HighestStudentRating = overall[0].HighestRating
+		}
+		if err := system.Create(ctx, snapshot); err != nil {
+			return fmt.Errorf("failed to save system stats: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Stats aggregated",
+		zap.String("service", "AnalyticsService"),
+		zap.Int("batches", len(byBatch)),
+		zap.Int("departments", len(byDepartment)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+// GetBatchAnalytics returns the latest stats of a batch with its snapshots
+// since the given time
+func (s *AnalyticsService) GetBatchAnalytics(ctx context.Context, batch string, since time.Time) (*BatchAnalytics, error) {
+	current, err := s.batches.GetByBatch(ctx, batch)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	history, err := s.batches.ListByBatch(ctx, batch, since)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchAnalytics{Batch: batch, Current: current, History: history}, nil
+}
+
+// GetDepartmentAnalytics returns the latest stats of a department with its
+// snapshots since the given time
+func (s *AnalyticsService) GetDepartmentAnalytics(ctx context.Context, department string, since time.Time) (*DepartmentAnalytics, error) {
+	current, err := s.departments.GetByDepartment(ctx, department)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	history, err := s.departments.ListByDepartment(ctx, department, since)
+	if err != nil {
+		return nil, err
+	}
+	return &DepartmentAnalytics{Department: department, Current: current, History: history}, nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Cohort groupings
+const (
+	CohortByPassingYear = "passing_year"
+	CohortByBatch       = "batch"
+)
+
+const (
+	// defaultCohortWeeks is how many weeks after enrolment are compared
+	// unless a request asks for another number
+	defaultCohortWeeks = 52
+	// maxCohortWeeks bounds one comparison, long enough to cover a five-year
+	// programme
+	maxCohortWeeks = 260
+)
+
+// ParseCohortGrouping validates a cohort grouping, defaulting to passing year
+func ParseCohortGrouping(groupBy string) (string, error) {
+	switch groupBy {
+	case "":
+		return CohortByPassingYear, nil
+	case CohortByPassingYear, CohortByBatch:
+		return groupBy, nil
+	default:
+		return "", fmt.Errorf("invalid cohort grouping %q: must be one of %s, %s", groupBy,
+			CohortByPassingYear, CohortByBatch)
+	}
+}
+
+// ParseCohortWeeks validates the number of weeks to compare, defaulting to 52
+func ParseCohortWeeks(value string) (int, error) {
+	if value == "" {
+		return defaultCohortWeeks, nil
+	}
+	weeks, err := strconv.Atoi(value)
+	if err != nil || weeks < 1 || weeks > maxCohortWeeks {
+		return 0, fmt.Errorf("weeks must be an integer between 1 and %d", maxCohortWeeks)
+	}
+	return weeks, nil
+}
+
+// CohortRequest selects the cohorts to compare
+type CohortRequest struct {
+	GroupBy    string
+	Cohorts    []string
+	Department string
+	Weeks      int
+}
+
+// CohortSeries is one cohort's week-by-week progress since enrolment
+type CohortSeries struct {
+	Cohort string                   `json:"cohort"`
+	Weeks  []models.CohortWeekStats `json:"weeks"`
+}
+
+// CohortDelta is how far a cohort is ahead of the baseline cohort at the
+// comparison week; negative values mean it is behind. MedianProblemsSolved
+// is nil unless both cohorts have it.
+type CohortDelta struct {
+	Cohort                   string   `json:"cohort"`
+	MedianProblemsSolved     *float64 `json:"median_problems_solved"`
+	MedianRating             float64  `json:"median_rating"`
+	ContestParticipationRate float64  `json:"contest_participation_rate"`
+}
+
+// CohortComparison lines cohorts up at the latest week every cohort has data
+// for, against the earliest cohort
+type CohortComparison struct {
+	Week     int           `json:"week"`
+	Baseline string        `json:"baseline"`
+	Deltas   []CohortDelta `json:"deltas"`
+}
+
+// CohortAnalytics compares cohorts of students aligned on weeks since their
+// enrolment
+type CohortAnalytics struct {
+	GroupBy    string            `json:"group_by"`
+	Department string            `json:"department,omitempty"`
+	Weeks      int               `json:"weeks"`
+	Cohorts    []CohortSeries    `json:"cohorts"`
+	Comparison *CohortComparison `json:"comparison,omitempty"`
+}
+
+// CohortService compares how cohorts of students, such as passing years,
+// progressed over the same number of weeks since they enrolled. Enrolment is
+// derived from the passing year with the institution calendar; weeks from
+// before a student's stats were first synced have no data, so older cohorts
+// can only be compared at weeks they were tracked for.
+type CohortService struct {
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewCohortService(students *StudentService, logger *zap.Logger) *CohortService {
+	return &CohortService{
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// Compare returns each cohort's weekly series and, when more than one cohort
+// has data, how the others compare with the earliest one
+func (s *CohortService) Compare(ctx context.Context, req CohortRequest) (*CohortAnalytics, error) {
+	start := time.Now()
+
+	rows, err := s.repo.ListCohortWeekStats(ctx, repository.CohortQuery{
+		GroupBy:    req.GroupBy,
+		Cohorts:    req.Cohorts,
+		Department: req.Department,
+		Weeks:      req.Weeks,
+
+		AcademicYearStart: s.calendar.AcademicYearStart(),
+		ProgrammeYears:    s.calendar.ProgrammeYears(),
+		Timezone:          s.calendar.Timezone(),
+	}, s.calendar.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute cohort stats: %w", err)
+	}
+
+	result := &CohortAnalytics{
+		GroupBy:    req.GroupBy,
+		Department: req.Department,
+		Weeks:      req.Weeks,
+		Cohorts:    []CohortSeries{},
+	}
+	for _, row := range rows {
+		n := len(result.Cohorts)
+		if n == 0 || result.Cohorts[n-1].Cohort != row.Cohort {
+			result.Cohorts = append(result.Cohorts, CohortSeries{Cohort: row.Cohort})
+			n++
+		}
+		result.Cohorts[n-1].Weeks = append(result.Cohorts[n-1].Weeks, row)
+	}
+	// Passing years and batch names sort by when the cohort started
+	sort.SliceStable(result.Cohorts, func(i, j int) bool {
+		return result.Cohorts[i].Cohort < result.Cohorts[j].Cohort
+	})
+	result.Comparison = compareCohorts(result.Cohorts)
+
+	s.logger.Info("Cohorts compared",
+		zap.String("service", "CohortService"),
+		zap.String("group_by", req.GroupBy),
+		zap.Int("cohorts", len(result.Cohorts)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return result, nil
+}
+
+// compareCohorts compares every cohort with the first at the latest week all
+// of them have data for. Cohorts synced only after enrolling miss their early
+// weeks, so there may be no such week.
+func compareCohorts(cohorts []CohortSeries) *CohortComparison {
+	if len(cohorts) < 2 {
+		return nil
+	}
+	common := make(map[int]int)
+	for _, cohort := range cohorts {
+		for _, stats := range cohort.Weeks {
+			common[stats.Week]++
+		}
+	}
+	week := -1
+	for w, n := range common {
+		if n == len(cohorts) && w > week {
+			week = w
+		}
+	}
+	if week == -1 {
+		return nil
+	}
+
+	baseline := cohortWeek(cohorts[0], week)
+	comparison := &CohortComparison{
+		Week:     week,
+		Baseline: cohorts[0].Cohort,
+	}
+	for _, cohort := range cohorts[1:] {
+		stats := cohortWeek(cohort, week)
+		if stats == nil {
+			continue
+		}
+		var solved *float64
+		if stats.MedianProblemsSolved != nil && baseline.MedianProblemsSolved != nil {
+			delta := *stats.MedianProblemsSolved - *baseline.MedianProblemsSolved
+			solved = &delta
+		}
+		comparison.Deltas = append(comparison.Deltas, CohortDelta{
+			Cohort:                   cohort.Cohort,
+			MedianProblemsSolved:     solved,
+			MedianRating:             stats.RatingP50 - baseline.RatingP50,
+			ContestParticipationRate: stats.ContestParticipationRate - baseline.ContestParticipationRate,
+		})
+	}
+	return comparison
+}
+
+func cohortWeek(cohort CohortSeries, week int) *models.CohortWeekStats {
+	for i := range cohort.Weeks {
+		if cohort.Weeks[i].Week == week {
+			return &cohort.Weeks[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ayush/ORBIT/internal/models"
+)
+
+func cohortSeries(cohort string, solved *float64, weeks ...int) CohortSeries {
+	series := CohortSeries{Cohort: cohort}
+	for _, week := range weeks {
+		series.Weeks = append(series.Weeks, models.CohortWeekStats{
+			Cohort:                   cohort,
+			Week:                     week,
+			MedianProblemsSolved:     solved,
+			RatingP50:                float64(1400 + week),
+			ContestParticipationRate: 0.5,
+		})
+	}
+	return series
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestCompareCohorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cohorts []CohortSeries
+		week    int
+		solved  []*float64 // expected problems solved delta per compared cohort
+		wantNil bool
+	}{
+		{
+			name:    "single cohort",
+			cohorts: []CohortSeries{cohortSeries("2025", floatPtr(10), 0, 1, 2)},
+			wantNil: true,
+		},
+		{
+			name: "latest shared week",
+			cohorts: []CohortSeries{
+				cohortSeries("2025", floatPtr(10), 0, 1, 2, 3),
+				cohortSeries("2026", floatPtr(25), 0, 1),
+			},
+			week:   1,
+			solved: []*float64{floatPtr(15)},
+		},
+		{
+			name: "weeks that do not start at zero",
+			cohorts: []CohortSeries{
+				cohortSeries("2025", floatPtr(10), 50, 51, 52, 53),
+				cohortSeries("2026", floatPtr(5), 10, 11, 52),
+			},
+			week:   52,
+			solved: []*float64{floatPtr(-5)},
+		},
+		{
+			name: "no shared week",
+			cohorts: []CohortSeries{
+				cohortSeries("2024", floatPtr(10), 100, 101),
+				cohortSeries("2026", floatPtr(5), 0, 1),
+			},
+			wantNil: true,
+		},
+		{
+			name: "problems solved unknown for the baseline",
+			cohorts: []CohortSeries{
+				cohortSeries("2025", nil, 0, 1),
+				cohortSeries("2026", floatPtr(5), 0, 1),
+			},
+			week:   1,
+			solved: []*float64{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareCohorts(tt.cohorts)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("compareCohorts() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("compareCohorts() = nil")
+			}
+			if got.Week != tt.week {
+				t.Errorf("compareCohorts() week = %d, want %d", got.Week, tt.week)
+			}
+			if got.Baseline != tt.cohorts[0].Cohort {
+				t.Errorf("compareCohorts() baseline = %q, want %q", got.Baseline, tt.cohorts[0].Cohort)
+			}
+			if len(got.Deltas) != len(tt.solved) {
+				t.Fatalf("compareCohorts() has %d deltas, want %d", len(got.Deltas), len(tt.solved))
+			}
+			for i, want := range tt.solved {
+				delta := got.Deltas[i]
+				switch {
+				case want == nil && delta.MedianProblemsSolved != nil:
+					t.Errorf("delta %d solved = %v, want nil", i, *delta.MedianProblemsSolved)
+				case want != nil && (delta.MedianProblemsSolved == nil || *delta.MedianProblemsSolved != *want):
+					t.Errorf("delta %d solved = %v, want %v", i, delta.MedianProblemsSolved, *want)
+				}
+				if delta.MedianRating != 0 {
+					t.Errorf("delta %d rating = %v, want 0 at the same week", i, delta.MedianRating)
+				}
+			}
+		})
+	}
+}
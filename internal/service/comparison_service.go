@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// minCompareStudents and maxCompareStudents bound one comparison
+	minCompareStudents = 2
+	maxCompareStudents = 5
+	// maxCompareDays bounds the length of the compared series
+	maxCompareDays = 365
+	// maxTagStrengths is how many of a student's strongest tags are compared
+	maxTagStrengths = 5
+)
+
+// ErrInvalidComparison is returned when a comparison asks for too few or too
+// many students or days
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// ComparedStudent is one student's current standing in a comparison. Rating,
+// problem count and difficulty mix come from their latest rating snapshot and
+// are nil without one. TagStrengths are their most solved topic tags as of
+// their last refresh.
+type ComparedStudent struct {
+	ID               uint           `json:"id"`
+	StudentID        string         `json:"student_id"`
+	Name             string         `json:"name"`
+	Batch            string         `json:"batch"`
+	Department       string         `json:"department"`
+	Rating           *int           `json:"rating"`
+	ProblemsCount    *int           `json:"problems_count"`
+	DifficultyMix    *DifficultyMix `json:"difficulty_mix"`
+	ContestsAttended int            `json:"contests_attended"`
+	ContestRating    *float64       `json:"contest_rating"`
+	TagStrengths     []TagStrength  `json:"tag_strengths"`
+}
+
+// TagStrength is how many problems of a topic tag a student has solved. Share
+// is that count over the student's solves across all tags, and a problem
+// counts once for each of its tags.
+type TagStrength struct {
+	Tag            string  `json:"tag"`
+	Slug           string  `json:"slug"`
+	Level          string  `json:"level"`
+	ProblemsSolved int     `json:"problems_solved"`
+	Share          float64 `json:"share"`
+}
+
+// ComparisonSeries is a student's rating and problem count at the end of each
+// day of the comparison, nil before their first snapshot
+type ComparisonSeries struct {
+	ID            uint   `json:"id"`
+	Rating        []*int `json:"rating"`
+	ProblemsCount []*int `json:"problems_count"`
+}
+
+// ContestResult is one student's result in a shared contest
+type ContestResult struct {
+	ID             uint    `json:"id"`
+	Ranking        int     `json:"ranking"`
+	Rating         float64 `json:"rating"`
+	ProblemsSolved int     `json:"problems_solved"`
+}
+
+// SharedContest is a contest at least two of the compared students entered,
+// with their results best ranking first
+type SharedContest struct {
+	ContestTitle string          `json:"contest_title"`
+	ContestDate  time.Time       `json:"contest_date"`
+	Results      []ContestResult `json:"results"`
+}
+
+// StudentComparison lines students up side by side. Series values share the
+// indexes of Dates.
+type StudentComparison struct {
+	Students       []ComparedStudent  `json:"students"`
+	Dates          []string           `json:"dates"`
+	Series         []ComparisonSeries `json:"series"`
+	SharedContests []SharedContest    `json:"shared_contests"`
+}
+
+// ComparisonService compares students head to head from their rating
+// snapshots, contest history and tag counts
+type ComparisonService struct {
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewComparisonService(students *StudentService, logger *zap.Logger) *ComparisonService {
+	return &ComparisonService{
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// Compare compares the students with the given IDs, in that order, over the
+// last days days
+func (s *ComparisonService) Compare(ctx context.Context, ids []uint, days int) (*StudentComparison, error) {
+	start := time.Now()
+	if len(ids) < minCompareStudents || len(ids) > maxCompareStudents {
+		return nil, fmt.Errorf("%w: between %d and %d students can be compared", ErrInvalidComparison, minCompareStudents, maxCompareStudents)
+	}
+	if days < 1 || days > maxCompareDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidComparison, maxCompareDays)
+	}
+
+	students, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get students: %w", err)
+	}
+	byID := make(map[uint]models.Student, len(students))
+	for _, student := range students {
+		byID[student.ID] = student
+	}
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("%w: student %d", ErrNotFound, id)
+		}
+	}
+
+	today := s.calendar.StartOfDay(s.calendar.Now())
+	first := today.AddDate(0, 0, -(days - 1))
+	history, err := s.repo.ListRatingHistory(ctx, ids, first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating history: %w", err)
+	}
+	contests, err := s.repo.ListContestHistoryForStudents(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contest history: %w", err)
+	}
+	tags, err := s.repo.ListTagStats(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag counts: %w", err)
+	}
+	contestsByStudent := make(map[uint][]models.ContestHistory)
+	for _, contest := range contests {
+		contestsByStudent[contest.StudentID] = append(contestsByStudent[contest.StudentID], contest)
+	}
+
+	result := &StudentComparison{
+		Students: make([]ComparedStudent, 0, len(ids)),
+		Series:   make([]ComparisonSeries, 0, len(ids)),
+	}
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		result.Dates = append(result.Dates, s.calendar.FormatDate(day))
+	}
+
+	for _, id := range ids {
+		// The last snapshot before the series starts carries into its first days
+		snapshots := history[id]
+		baseline, err := s.repo.GetBaselineRating(ctx, id, first, first)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get rating before %s: %w", s.calendar.FormatDate(first), err)
+		}
+		if baseline != nil && baseline.RecordedAt.Before(first) {
+			snapshots = append([]models.Rating{*baseline}, snapshots...)
+		}
+
+		compared := compareStudent(byID[id], snapshots, contestsByStudent[id])
+		compared.TagStrengths = tagStrengths(tags[id])
+		result.Students = append(result.Students, compared)
+		result.Series = append(result.Series, s.series(id, snapshots, first, len(result.Dates)))
+	}
+	result.SharedContests = sharedContests(contests)
+
+	s.logger.Info("Students compared",
+		zap.String("service", "ComparisonService"),
+		zap.Uints("student_ids", ids),
+		zap.Int("days", days),
+		zap.Int("shared_contests", len(result.SharedContests)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return result, nil
+}
+
+// series samples the snapshots, oldest first, at the end of each day
+func (s *ComparisonService) series(id uint, snapshots []models.Rating, first time.Time, days int) ComparisonSeries {
+	series := ComparisonSeries{
+		ID:            id,
+		Rating:        make([]*int, days),
+		ProblemsCount: make([]*int, days),
+	}
+	next := 0
+	var current *models.Rating
+	for i := 0; i < days; i++ {
+		end := first.AddDate(0, 0, i+1)
+		for next < len(snapshots) && snapshots[next].RecordedAt.Before(end) {
+			current = &snapshots[next]
+			next++
+		}
+		if current != nil {
+			series.Rating[i] = &current.Rating
+			series.ProblemsCount[i] = &current.ProblemsCount
+		}
+	}
+	return series
+}
+
+func compareStudent(student models.Student, snapshots []models.Rating, contests []models.ContestHistory) ComparedStudent {
+	compared := ComparedStudent{
+		ID:               student.ID,
+		StudentID:        student.StudentID,
+		Name:             student.Name,
+		Batch:            student.Batch,
+		Department:       student.Department,
+		ContestsAttended: len(contests),
+	}
+	if len(contests) > 0 {
+		// Contest history is newest first
+		compared.ContestRating = &contests[0].Rating
+	}
+	if len(snapshots) == 0 {
+		return compared
+	}
+
+	latest := snapshots[len(snapshots)-1]
+	compared.Rating = &latest.Rating
+	compared.ProblemsCount = &latest.ProblemsCount
+	compared.DifficultyMix = &DifficultyMix{
+		Easy:   latest.EasyCount,
+		Medium: latest.MediumCount,
+		Hard:   latest.HardCount,
+	}
+	compared.DifficultyMix.computeShares()
+	return compared
+}
+
+// tagStrengths returns the most solved of a student's tags, given most solved
+// first
+func tagStrengths(stats []models.StudentTagStat) []TagStrength {
+	total := 0
+	for _, stat := range stats {
+		total += stat.ProblemsSolved
+	}
+
+	strengths := []TagStrength{}
+	for _, stat := range stats {
+		if len(strengths) == maxTagStrengths || stat.ProblemsSolved == 0 {
+			break
+		}
+		strengths = append(strengths, TagStrength{
+			Tag:            stat.TagName,
+			Slug:           stat.TagSlug,
+			Level:          stat.Level,
+			ProblemsSolved: stat.ProblemsSolved,
+			Share:          float64(stat.ProblemsSolved) / float64(total),
+		})
+	}
+	return strengths
+}
+
+// sharedContests groups contest history by contest and keeps the contests
+// more than one student entered, newest first
+func sharedContests(history []models.ContestHistory) []SharedContest {
+	byTitle := make(map[string]*SharedContest)
+	for _, entry := range history {
+		contest, ok := byTitle[entry.ContestTitle]
+		if !ok {
+			contest = &SharedContest{ContestTitle: entry.ContestTitle, ContestDate: entry.ContestDate}
+			byTitle[entry.ContestTitle] = contest
+		}
+		contest.Results = append(contest.Results, ContestResult{
+			ID:             entry.StudentID,
+			Ranking:        entry.Ranking,
+			Rating:         entry.Rating,
+			ProblemsSolved: entry.ProblemsSolved,
+		})
+	}
+
+	shared := []SharedContest{}
+	for _, contest := range byTitle {
+		if len(contest.Results) < 2 {
+			continue
+		}
+		// Unranked entries sort last
+		sort.Slice(contest.Results, func(i, j int) bool {
+			return rankOrder(contest.Results[i].Ranking) < rankOrder(contest.Results[j].Ranking)
+		})
+		shared = append(shared, *contest)
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if !shared[i].ContestDate.Equal(shared[j].ContestDate) {
+			return shared[i].ContestDate.After(shared[j].ContestDate)
+		}
+		return shared[i].ContestTitle < shared[j].ContestTitle
+	})
+	return shared
+}
+
+func rankOrder(ranking int) int {
+	if ranking <= 0 {
+		return math.MaxInt
+	}
+	return ranking
+}
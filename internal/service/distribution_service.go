@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxDistributionBuckets bounds how many buckets one histogram may hold
+const maxDistributionBuckets = 200
+
+// defaultBucketSizes is the bucket width of each metric unless a request
+// asks for another one
+var defaultBucketSizes = map[string]float64{
+	models.LeaderboardMetricRating:         100,
+	models.LeaderboardMetricProblemsSolved: 50,
+	models.LeaderboardMetricContestRating:  100,
+}
+
+// ErrInvalidBucketSize is returned for bucket widths that are not positive or
+// split the values into more buckets than a histogram may hold
+var ErrInvalidBucketSize = errors.New("invalid bucket size")
+
+// ParseDistributionMetric validates a distribution metric, defaulting to rating
+func ParseDistributionMetric(metric string) (string, error) {
+	switch metric {
+	case "":
+		return models.LeaderboardMetricRating, nil
+	case models.LeaderboardMetricRating, models.LeaderboardMetricProblemsSolved, models.LeaderboardMetricContestRating:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("invalid distribution metric %q: must be one of %s, %s, %s", metric,
+			models.LeaderboardMetricRating, models.LeaderboardMetricProblemsSolved, models.LeaderboardMetricContestRating)
+	}
+}
+
+// Distribution is a histogram of students' latest values for a metric.
+// Buckets run contiguously from the lowest to the highest value.
+type Distribution struct {
+	Metric     string                      `json:"metric"`
+	BucketSize float64                     `json:"bucket_size"`
+	Department string                      `json:"department,omitempty"`
+	Batch      string                      `json:"batch,omitempty"`
+	Total      int                         `json:"total"`
+	Buckets    []models.DistributionBucket `json:"buckets"`
+}
+
+// DistributionService places students among their peers: percentile ranks
+// for individual students and histograms for groups
+type DistributionService struct {
+	repo   *repository.StudentRepository
+	logger *zap.Logger
+}
+
+func NewDistributionService(students *StudentService, logger *zap.Logger) *DistributionService {
+	return &DistributionService{
+		repo:   students.repo,
+		logger: logger,
+	}
+}
+
+// GetPercentiles returns a student's percentiles, from 0 to 100, within their
+// department, batch and the institution
+func (s *DistributionService) GetPercentiles(ctx context.Context, studentID uint) (*models.StudentPercentiles, error) {
+	percentiles, err := s.repo.GetStudentPercentiles(ctx, studentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get percentiles: %w", err)
+	}
+
+	for _, metric := range []*models.MetricPercentiles{&percentiles.Rating, &percentiles.ProblemsSolved, &percentiles.ContestRating} {
+		for _, value := range []*float64{metric.Department, metric.Batch, metric.Institution} {
+			if value != nil {
+				*value = roundTenth(*value * 100)
+			}
+		}
+	}
+	return percentiles, nil
+}
+
+// GetDistribution builds the histogram of a metric with buckets bucketSize
+// wide, or the metric's default width when bucketSize is 0
+func (s *DistributionService) GetDistribution(ctx context.Context, metric string, bucketSize float64, department, batch string) (*Distribution, error) {
+	start := time.Now()
+	if bucketSize == 0 {
+		bucketSize = defaultBucketSizes[metric]
+	}
+	if bucketSize <= 0 {
+		return nil, fmt.Errorf("%w: bucket size must be positive", ErrInvalidBucketSize)
+	}
+
+	counts, err := s.repo.GetDistribution(ctx, metric, bucketSize, department, batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get distribution: %w", err)
+	}
+
+	result := &Distribution{
+		Metric:     metric,
+		BucketSize: bucketSize,
+		Department: department,
+		Batch:      batch,
+		Buckets:    []models.DistributionBucket{},
+	}
+	if len(counts) == 0 {
+		return result, nil
+	}
+
+	first, last := 0, 0
+	seen := false
+	for bucket := range counts {
+		if !seen || bucket < first {
+			first = bucket
+		}
+		if !seen || bucket > last {
+			last = bucket
+		}
+		seen = true
+	}
+	if last-first+1 > maxDistributionBuckets {
+		return nil, fmt.Errorf("%w: %d buckets needed, at most %d are allowed", ErrInvalidBucketSize, last-first+1, maxDistributionBuckets)
+	}
+
+	for bucket := first; bucket <= last; bucket++ {
+		result.Buckets = append(result.Buckets, models.DistributionBucket{
+			From:  float64(bucket) * bucketSize,
+			To:    float64(bucket+1) * bucketSize,
+			Count: counts[bucket],
+		})
+		result.Total += counts[bucket]
+	}
+
+	s.logger.Info("Distribution built",
+		zap.String("service", "DistributionService"),
+		zap.String("metric", metric),
+		zap.Float64("bucket_size", bucketSize),
+		zap.Int("buckets", len(result.Buckets)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return result, nil
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// exportPageSize is the number of students read from the database at a time
+const exportPageSize = 500
+
+// Export sheet names
+const (
+	sheetStudents       = "Students"
+	sheetContestHistory = "Contest History"
+	sheetWeeklyStats    = "Weekly Stats"
+)
+
+// ExportFilter narrows an export to a department and/or batch
+type ExportFilter struct {
+	Department string
+	Batch      string
+}
+
+var studentExportHeader = []string{
+	"student_id", "name", "email", "leetcode_id", "passing_year", "batch", "department",
+	"total_solved", "easy_solved", "medium_solved", "hard_solved", "global_ranking",
+	"current_rating", "rating_recorded_at", "contest_rating", "contests_attended",
+	"best_contest_rating", "best_contest_ranking", "last_contest_at", "stats_updated_at",
+}
+
+var contestExportHeader = []string{
+	"student_id", "name", "contest_title", "contest_date", "rating", "ranking",
+	"problems_solved", "finish_time_seconds",
+}
+
+var weeklyExportHeader = []string{
+	"student_id", "name", "week_start", "week_end", "problems_solved", "easy_solved",
+	"medium_solved", "hard_solved", "contests_attended", "average_rating",
+}
+
+// ExportService writes the student roster and stats as CSV or XLSX. Students
+// are read a page at a time so large rosters never sit in memory at once.
+type ExportService struct {
+	repo   *repository.StudentRepository
+	logger *zap.Logger
+}
+
+func NewExportService(students *StudentService, logger *zap.Logger) *ExportService {
+	return &ExportService{
+		repo:   students.repo,
+		logger: logger,
+	}
+}
+
+// WriteCSV streams the roster with stats and contest summary as CSV
+func (s *ExportService) WriteCSV(ctx context.Context, w io.Writer, filter ExportFilter) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(studentExportHeader); err != nil {
+		return err
+	}
+
+	err := s.eachPage(ctx, filter, func(rows []models.StudentExportRow) error {
+		for i := range rows {
+			if err := out.Write(csvCells(studentExportValues(&rows[i]))); err != nil {
+				return err
+			}
+		}
+		// Push each page to the client instead of buffering the whole file
+		out.Flush()
+		return out.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// WriteXLSX writes a workbook with students, contest history and weekly stats
+// sheets. Rows go through excelize stream writers, which spill to disk rather
+// than holding every cell in memory.
+func (s *ExportService) WriteXLSX(ctx context.Context, w io.Writer, filter ExportFilter) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", sheetStudents); err != nil {
+		return err
+	}
+	for _, sheet := range []string{sheetContestHistory, sheetWeeklyStats} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writeStudentSheet(ctx, f, filter); err != nil {
+		return fmt.Errorf("failed to write students sheet: %w", err)
+	}
+	if err := s.writeContestSheet(ctx, f, filter); err != nil {
+		return fmt.Errorf("failed to write contest history sheet: %w", err)
+	}
+	if err := s.writeWeeklySheet(ctx, f, filter); err != nil {
+		return fmt.Errorf("failed to write weekly stats sheet: %w", err)
+	}
+
+	return f.Write(w)
+}
+
+func (s *ExportService) writeStudentSheet(ctx context.Context, f *excelize.File, filter ExportFilter) error {
+	sheet, err := newSheetWriter(f, sheetStudents, studentExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.eachPage(ctx, filter, func(rows []models.StudentExportRow) error {
+		for i := range rows {
+			if err := sheet.write(studentExportValues(&rows[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sheet.stream.Flush()
+}
+
+func (s *ExportService) writeContestSheet(ctx context.Context, f *excelize.File, filter ExportFilter) error {
+	sheet, err := newSheetWriter(f, sheetContestHistory, contestExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.eachPage(ctx, filter, func(rows []models.StudentExportRow) error {
+		ids, byID := indexExportRows(rows)
+		history, err := s.repo.ListContestHistoryForStudents(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to load contest history: %w", err)
+		}
+
+		for _, contest := range history {
+			student := byID[contest.StudentID]
+			if err := sheet.write([]interface{}{
+				student.StudentID,
+				student.Name,
+				contest.ContestTitle,
+				formatExportTime(&contest.ContestDate),
+				contest.Rating,
+				contest.Ranking,
+				contest.ProblemsSolved,
+				contest.FinishTimeSeconds,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sheet.stream.Flush()
+}
+
+func (s *ExportService) writeWeeklySheet(ctx context.Context, f *excelize.File, filter ExportFilter) error {
+	sheet, err := newSheetWriter(f, sheetWeeklyStats, weeklyExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.eachPage(ctx, filter, func(rows []models.StudentExportRow) error {
+		ids, byID := indexExportRows(rows)
+		weeks, err := s.repo.ListWeeklyStatsForStudents(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to load weekly stats: %w", err)
+		}
+
+		for _, week := range weeks {
+			student := byID[week.StudentID]
+			if err := sheet.write([]interface{}{
+				student.StudentID,
+				student.Name,
+				week.WeekStart.Format("2006-01-02"),
+				week.WeekEnd.Format("2006-01-02"),
+				week.ProblemsSolved,
+				week.EasySolved,
+				week.MediumSolved,
+				week.HardSolved,
+				week.ContestsAttended,
+				week.AverageRating,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sheet.stream.Flush()
+}
+
+// eachPage calls fn with successive pages of matching students in ID order
+func (s *ExportService) eachPage(ctx context.Context, filter ExportFilter, fn func([]models.StudentExportRow) error) error {
+	var afterID uint
+	for {
+		rows, err := s.repo.ListExportRows(ctx, afterID, exportPageSize, filter.Department, filter.Batch)
+		if err != nil {
+			return fmt.Errorf("failed to load students: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := fn(rows); err != nil {
+			return err
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// sheetWriter appends rows to one worksheet through a stream writer
+type sheetWriter struct {
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newSheetWriter(f *excelize.File, sheet string, header []string) (*sheetWriter, error) {
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	w := &sheetWriter{stream: stream}
+	values := make([]interface{}, len(header))
+	for i, name := range header {
+		values[i] = name
+	}
+	if err := w.write(values); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *sheetWriter) write(values []interface{}) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, values)
+}
+
+// studentExportValues returns the cells of a student row; numbers stay typed
+// so spreadsheets can sort and sum them, and missing values are nil
+func studentExportValues(row *models.StudentExportRow) []interface{} {
+	return []interface{}{
+		row.StudentID,
+		row.Name,
+		row.Email,
+		row.LeetcodeID,
+		row.PassingYear,
+		row.Batch,
+		row.Department,
+		row.TotalSolved,
+		row.EasySolved,
+		row.MediumSolved,
+		row.HardSolved,
+		row.GlobalRanking,
+		optionalInt(row.CurrentRating),
+		formatExportTime(row.RatingRecordedAt),
+		row.ContestRating,
+		row.ContestsAttended,
+		optionalFloat(row.BestContestRating),
+		optionalInt(row.BestContestRanking),
+		formatExportTime(row.LastContestAt),
+		formatExportTime(row.StatsUpdatedAt),
+	}
+}
+
+// csvCells renders typed cell values as CSV fields
+func csvCells(values []interface{}) []string {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			cells[i] = ""
+		case string:
+			cells[i] = v
+		case int:
+			cells[i] = strconv.Itoa(v)
+		case float64:
+			cells[i] = strconv.FormatFloat(v, 'f', 2, 64)
+		default:
+			cells[i] = fmt.Sprint(v)
+		}
+	}
+	return cells
+}
+
+func indexExportRows(rows []models.StudentExportRow) ([]uint, map[uint]*models.StudentExportRow) {
+	ids := make([]uint, len(rows))
+	byID := make(map[uint]*models.StudentExportRow, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].ID
+		byID[rows[i].ID] = &rows[i]
+	}
+	return ids, byID
+}
+
+func optionalInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optionalFloat(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Forecast models
+const (
+	ForecastModelLinear      = "linear"
+	ForecastModelExponential = "exponential"
+)
+
+// Forecast metrics
+const (
+	ForecastMetricRating         = "rating"
+	ForecastMetricProblemsSolved = "problems_solved"
+)
+
+// Projection statuses of a student against a batch threshold
+const (
+	ForecastStatusAbove        = "above"             // already at or above the threshold
+	ForecastStatusOnTrack      = "on_track"          // even the lower bound crosses it
+	ForecastStatusPossible     = "possible"          // the prediction crosses it, the lower bound does not
+	ForecastStatusOffTrack     = "off_track"         // the prediction falls short
+	ForecastStatusInsufficient = "insufficient_data" // too few snapshots to fit a trend
+)
+
+const (
+	// forecastHistory is how far back snapshots are used to fit a trend
+	forecastHistory = 180 * 24 * time.Hour
+	// minForecastSnapshots is the fewest snapshots a trend is fitted to
+	minForecastSnapshots = 3
+	// minForecastDays is the fewest distinct days those snapshots must span;
+	// snapshots bunched within a day make the slope and its interval explode
+	minForecastDays = 3
+	// maxForecastHorizon bounds how far ahead a trend is extrapolated
+	maxForecastHorizon = 2 * 365 * 24 * time.Hour
+	// forecastConfidence is the coverage of the prediction intervals
+	forecastConfidence = 0.95
+)
+
+var (
+	// ErrInsufficientHistory is returned when a student has too few recent
+	// rating snapshots to fit a trend
+	ErrInsufficientHistory = errors.New("not enough rating history to forecast")
+	// ErrNonPositiveValues is returned when an exponential trend is fitted to
+	// values that are zero or negative, which have no logarithm
+	ErrNonPositiveValues = errors.New("exponential trends need positive values")
+	// ErrInvalidForecastDate is returned for dates that are not in the future
+	// or lie beyond the forecast horizon
+	ErrInvalidForecastDate = errors.New("invalid forecast date")
+)
+
+// ParseForecastModel validates a forecast model, defaulting to linear
+func ParseForecastModel(model string) (string, error) {
+	switch model {
+	case "":
+		return ForecastModelLinear, nil
+	case ForecastModelLinear, ForecastModelExponential:
+		return model, nil
+	default:
+		return "", fmt.Errorf("invalid forecast model %q: must be one of %s, %s", model,
+			ForecastModelLinear, ForecastModelExponential)
+	}
+}
+
+// ParseForecastMetric validates a forecast metric, defaulting to rating
+func ParseForecastMetric(metric string) (string, error) {
+	switch metric {
+	case "":
+		return ForecastMetricRating, nil
+	case ForecastMetricRating, ForecastMetricProblemsSolved:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("invalid forecast metric %q: must be one of %s, %s", metric,
+			ForecastMetricRating, ForecastMetricProblemsSolved)
+	}
+}
+
+// ForecastPrediction is a metric's current value and its predicted value at
+// the forecast date with a prediction interval. Model is the model the trend
+// was fitted with, linear when an exponential one was asked for but the
+// metric has been zero.
+type ForecastPrediction struct {
+	Model     string  `json:"model"`
+	Current   float64 `json:"current"`
+	Predicted float64 `json:"predicted"`
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	PerWeek   float64 `json:"per_week"` // trend change over the week before the date
+}
+
+// StudentForecast is a student's predicted rating and problem count at a date
+type StudentForecast struct {
+	StudentID     uint               `json:"student_id"`
+	Model         string             `json:"model"`
+	Date          string             `json:"date"`
+	Confidence    float64            `json:"confidence"`
+	Snapshots     int                `json:"snapshots"`
+	HistoryStart  time.Time          `json:"history_start"`
+	LastSnapshot  time.Time          `json:"last_snapshot"`
+	Rating        ForecastPrediction `json:"rating"`
+	ProblemsCount ForecastPrediction `json:"problems_count"`
+}
+
+// BatchForecastEntry is one student's projection against a batch threshold
+type BatchForecastEntry struct {
+	ID        uint     `json:"id"`
+	StudentID string   `json:"student_id"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Current   *float64 `json:"current"`
+	Predicted *float64 `json:"predicted"`
+	Lower     *float64 `json:"lower"`
+	Upper     *float64 `json:"upper"`
+}
+
+// BatchForecast counts how many students of a batch are projected to cross a
+// threshold by a date. ProjectedToCross counts students below the threshold
+// today whose prediction reaches it.
+type BatchForecast struct {
+	Batch            string               `json:"batch"`
+	Metric           string               `json:"metric"`
+	Model            string               `json:"model"`
+	Threshold        float64              `json:"threshold"`
+	Date             string               `json:"date"`
+	Confidence       float64              `json:"confidence"`
+	TotalStudents    int                  `json:"total_students"`
+	ProjectedToCross int                  `json:"projected_to_cross"`
+	StatusCounts     map[string]int       `json:"status_counts"`
+	Students         []BatchForecastEntry `json:"students"`
+}
+
+// ForecastService extrapolates students' rating and problem count trends
+// from their rating snapshots
+type ForecastService struct {
+	students *StudentService
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewForecastService(students *StudentService, logger *zap.Logger) *ForecastService {
+	return &ForecastService{
+		students: students,
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// ForecastStudent predicts a student's rating and problem count at date
+func (s *ForecastService) ForecastStudent(ctx context.Context, id uint, date time.Time, model string) (*StudentForecast, error) {
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+	if _, err := s.students.GetStudent(ctx, id); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.ListRatingHistory(ctx, []uint{id}, s.calendar.Now().Add(-forecastHistory))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating history: %w", err)
+	}
+	return s.forecast(id, history[id], date, model)
+}
+
+// ForecastBatch projects every student of a batch against a threshold on a
+// metric at date
+func (s *ForecastService) ForecastBatch(ctx context.Context, batch, metric string, threshold float64, date time.Time, model string) (*BatchForecast, error) {
+	if err := s.validateDate(date); err != nil {
+		return nil, err
+	}
+
+	students, err := s.repo.ListStudents(ctx, 0, -1, "", batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list students: %w", err)
+	}
+	if len(students) == 0 {
+		return nil, ErrNotFound
+	}
+	ids := make([]uint, len(students))
+	for i, student := range students {
+		ids[i] = student.ID
+	}
+	history, err := s.repo.ListRatingHistory(ctx, ids, s.calendar.Now().Add(-forecastHistory))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating history: %w", err)
+	}
+
+	result := &BatchForecast{
+		Batch:         batch,
+		Metric:        metric,
+		Model:         model,
+		Threshold:     threshold,
+		Date:          s.calendar.FormatDate(date),
+		Confidence:    forecastConfidence,
+		TotalStudents: len(students),
+		StatusCounts:  make(map[string]int),
+		Students:      make([]BatchForecastEntry, 0, len(students)),
+	}
+	for _, student := range students {
+		entry := BatchForecastEntry{
+			ID:        student.ID,
+			StudentID: student.StudentID,
+			Name:      student.Name,
+			Status:    ForecastStatusInsufficient,
+		}
+		forecast, err := s.forecast(student.ID, history[student.ID], date, model)
+		switch {
+		case errors.Is(err, ErrInsufficientHistory):
+		case err != nil:
+			return nil, fmt.Errorf("failed to forecast student %d: %w", student.ID, err)
+		default:
+			prediction := forecast.Rating
+			if metric == ForecastMetricProblemsSolved {
+				prediction = forecast.ProblemsCount
+			}
+			entry.Current = &prediction.Current
+			entry.Predicted = &prediction.Predicted
+			entry.Lower = &prediction.Lower
+			entry.Upper = &prediction.Upper
+			entry.Status = thresholdStatus(prediction, threshold)
+		}
+		if entry.Status == ForecastStatusOnTrack || entry.Status == ForecastStatusPossible {
+			result.ProjectedToCross++
+		}
+		result.StatusCounts[entry.Status]++
+		result.Students = append(result.Students, entry)
+	}
+
+	// Highest predictions first, students without a forecast last
+	sort.SliceStable(result.Students, func(i, j int) bool {
+		a, b := result.Students[i].Predicted, result.Students[j].Predicted
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+	return result, nil
+}
+
+// validateDate rejects forecast dates that are not in the future or lie
+// further ahead than trends can sensibly be extrapolated
+func (s *ForecastService) validateDate(date time.Time) error {
+	now := s.calendar.Now()
+	if !date.After(now) {
+		return fmt.Errorf("%w: date must be in the future", ErrInvalidForecastDate)
+	}
+	if date.Sub(now) > maxForecastHorizon {
+		return fmt.Errorf("%w: date must be within %d days", ErrInvalidForecastDate, int(maxForecastHorizon.Hours()/24))
+	}
+	return nil
+}
+
+// forecast fits the model to a student's snapshots, oldest first. Days since
+// the first snapshot are the regressor. Problem counts never decrease, so
+// their prediction is kept at or above the current count.
+func (s *ForecastService) forecast(studentID uint, snapshots []models.Rating, date time.Time, model string) (*StudentForecast, error) {
+	if len(snapshots) < minForecastSnapshots {
+		return nil, ErrInsufficientHistory
+	}
+	days := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		days[s.calendar.FormatDate(snapshot.RecordedAt)] = true
+	}
+	if len(days) < minForecastDays {
+		return nil, fmt.Errorf("%w: snapshots must span at least %d days", ErrInsufficientHistory, minForecastDays)
+	}
+
+	first := snapshots[0].RecordedAt
+	last := snapshots[len(snapshots)-1]
+	xs := make([]float64, len(snapshots))
+	ratings := make([]float64, len(snapshots))
+	problems := make([]float64, len(snapshots))
+	for i, snapshot := range snapshots {
+		xs[i] = snapshot.RecordedAt.Sub(first).Hours() / 24
+		ratings[i] = float64(snapshot.Rating)
+		problems[i] = float64(snapshot.ProblemsCount)
+	}
+	x := date.Sub(first).Hours() / 24
+
+	ratingPrediction, err := predictTrend(xs, ratings, x, model)
+	if err != nil {
+		return nil, err
+	}
+	problemsPrediction, err := predictTrend(xs, problems, x, model)
+	if err != nil {
+		return nil, err
+	}
+	problemsPrediction.Predicted = math.Max(problemsPrediction.Predicted, problemsPrediction.Current)
+	problemsPrediction.Lower = math.Max(problemsPrediction.Lower, problemsPrediction.Current)
+	problemsPrediction.Upper = math.Max(problemsPrediction.Upper, problemsPrediction.Current)
+
+	return &StudentForecast{
+		StudentID:     studentID,
+		Model:         model,
+		Date:          s.calendar.FormatDate(date),
+		Confidence:    forecastConfidence,
+		Snapshots:     len(snapshots),
+		HistoryStart:  first,
+		LastSnapshot:  last.RecordedAt,
+		Rating:        ratingPrediction,
+		ProblemsCount: problemsPrediction,
+	}, nil
+}
+
+// predictTrend fits the model to the points and predicts the value at x,
+// with the last point's value as the current one. An exponential model falls
+// back to a linear one for metrics that have been zero, such as the rating of
+// a student yet to enter a contest.
+func predictTrend(xs, ys []float64, x float64, model string) (ForecastPrediction, error) {
+	fit, err := fitTrend(xs, ys, model == ForecastModelExponential)
+	if errors.Is(err, ErrNonPositiveValues) {
+		model = ForecastModelLinear
+		fit, err = fitTrend(xs, ys, false)
+	}
+	if err != nil {
+		return ForecastPrediction{}, err
+	}
+	prediction, err := fit.prediction(ys[len(ys)-1], x)
+	if err != nil {
+		return ForecastPrediction{}, err
+	}
+	prediction.Model = model
+	return prediction, nil
+}
+
+func thresholdStatus(prediction ForecastPrediction, threshold float64) string {
+	switch {
+	case prediction.Current >= threshold:
+		return ForecastStatusAbove
+	case prediction.Lower >= threshold:
+		return ForecastStatusOnTrack
+	case prediction.Predicted >= threshold:
+		return ForecastStatusPossible
+	default:
+		return ForecastStatusOffTrack
+	}
+}
+
+// trendFit is an ordinary least squares line through the points, fitted to
+// the logarithm of the values for exponential trends
+type trendFit struct {
+	slope       float64
+	intercept   float64
+	stderr      float64 // standard error of the residuals
+	meanX       float64
+	sxx         float64
+	n           int
+	exponential bool
+}
+
+func fitTrend(xs, ys []float64, exponential bool) (*trendFit, error) {
+	n := len(xs)
+	if n < minForecastSnapshots {
+		return nil, ErrInsufficientHistory
+	}
+
+	values := ys
+	if exponential {
+		values = make([]float64, n)
+		for i, y := range ys {
+			if y <= 0 {
+				return nil, ErrNonPositiveValues
+			}
+			values[i] = math.Log(y)
+		}
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += values[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (values[i] - meanY)
+	}
+	// Snapshots taken at the same moment do not show a trend
+	if sxx == 0 {
+		return nil, ErrInsufficientHistory
+	}
+
+	fit := &trendFit{
+		slope:       sxy / sxx,
+		meanX:       meanX,
+		sxx:         sxx,
+		n:           n,
+		exponential: exponential,
+	}
+	fit.intercept = meanY - fit.slope*meanX
+
+	var residuals float64
+	for i := range xs {
+		r := values[i] - (fit.intercept + fit.slope*xs[i])
+		residuals += r * r
+	}
+	fit.stderr = math.Sqrt(residuals / float64(n-2))
+	return fit, nil
+}
+
+// predict returns the trend at x with its prediction interval
+func (f *trendFit) predict(x float64) (mid, lower, upper float64) {
+	mid = f.intercept + f.slope*x
+	half := tCritical95(f.n-2) * f.stderr * math.Sqrt(1+1/float64(f.n)+(x-f.meanX)*(x-f.meanX)/f.sxx)
+	lower, upper = mid-half, mid+half
+	if f.exponential {
+		return math.Exp(mid), math.Exp(lower), math.Exp(upper)
+	}
+	return mid, lower, upper
+}
+
+// prediction rounds the trend at x for the response. Extrapolating an
+// exponential trend can overflow, which JSON cannot encode, so non-finite
+// results are reported as insufficient history.
+func (f *trendFit) prediction(current, x float64) (ForecastPrediction, error) {
+	mid, lower, upper := f.predict(x)
+	weekAgo, _, _ := f.predict(x - 7)
+	for _, value := range []float64{mid, lower, upper, weekAgo} {
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return ForecastPrediction{}, fmt.Errorf("%w: the trend cannot be extrapolated that far", ErrInsufficientHistory)
+		}
+	}
+	return ForecastPrediction{
+		Current:   current,
+		Predicted: roundTenth(mid),
+		Lower:     roundTenth(lower),
+		Upper:     roundTenth(upper),
+		PerWeek:   roundTenth(mid - weekAgo),
+	}, nil
+}
+
+// tCritical95 is the two-sided 95% critical value of Student's t
+// distribution, approximated by the normal one beyond 30 degrees of freedom
+func tCritical95(df int) float64 {
+	table := []float64{
+		12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+		2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+		2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+	}
+	if df >= 1 && df <= len(table) {
+		return table[df-1]
+	}
+	return 1.96
+}
+
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
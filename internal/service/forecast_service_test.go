@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFitTrend(t *testing.T) {
+	tests := []struct {
+		name        string
+		xs, ys      []float64
+		exponential bool
+		wantErr     error
+		slope       float64
+		intercept   float64
+	}{
+		{
+			name:      "exact line",
+			xs:        []float64{0, 1, 2, 3},
+			ys:        []float64{10, 12, 14, 16},
+			slope:     2,
+			intercept: 10,
+		},
+		{
+			name:        "exact exponential",
+			xs:          []float64{0, 1, 2},
+			ys:          []float64{1, math.E, math.E * math.E},
+			exponential: true,
+			slope:       1,
+			intercept:   0,
+		},
+		{
+			name:    "too few points",
+			xs:      []float64{0, 1},
+			ys:      []float64{1, 2},
+			wantErr: ErrInsufficientHistory,
+		},
+		{
+			name:    "same moment",
+			xs:      []float64{5, 5, 5},
+			ys:      []float64{1, 2, 3},
+			wantErr: ErrInsufficientHistory,
+		},
+		{
+			name:        "zero under exponential",
+			xs:          []float64{0, 1, 2},
+			ys:          []float64{0, 1, 2},
+			exponential: true,
+			wantErr:     ErrNonPositiveValues,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fit, err := fitTrend(tt.xs, tt.ys, tt.exponential)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("fitTrend() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fitTrend() error = %v", err)
+			}
+			if math.Abs(fit.slope-tt.slope) > 1e-9 || math.Abs(fit.intercept-tt.intercept) > 1e-9 {
+				t.Errorf("fitTrend() = %v + %v x, want %v + %v x", fit.intercept, fit.slope, tt.intercept, tt.slope)
+			}
+		})
+	}
+}
+
+func TestTrendFitPredict(t *testing.T) {
+	tests := []struct {
+		name        string
+		xs, ys      []float64
+		exponential bool
+		x           float64
+		mid         float64
+	}{
+		{
+			name: "linear extrapolation",
+			xs:   []float64{0, 1, 2, 3},
+			ys:   []float64{10, 13, 14, 17},
+			x:    10,
+			mid:  10.2 + 2.2*10,
+		},
+		{
+			name:        "exponential extrapolation",
+			xs:          []float64{0, 1, 2},
+			ys:          []float64{1, 2, 4},
+			exponential: true,
+			x:           4,
+			mid:         16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fit, err := fitTrend(tt.xs, tt.ys, tt.exponential)
+			if err != nil {
+				t.Fatalf("fitTrend() error = %v", err)
+			}
+			mid, lower, upper := fit.predict(tt.x)
+			if math.Abs(mid-tt.mid) > 1e-9 {
+				t.Errorf("predict() mid = %v, want %v", mid, tt.mid)
+			}
+			if lower > mid || upper < mid {
+				t.Errorf("predict() interval [%v, %v] does not contain %v", lower, upper, mid)
+			}
+		})
+	}
+}
+
+func TestPredictTrend(t *testing.T) {
+	tests := []struct {
+		name      string
+		xs, ys    []float64
+		x         float64
+		model     string
+		wantModel string
+		wantErr   error
+	}{
+		{
+			name:      "linear",
+			xs:        []float64{0, 1, 2},
+			ys:        []float64{1, 2, 3},
+			x:         5,
+			model:     ForecastModelLinear,
+			wantModel: ForecastModelLinear,
+		},
+		{
+			name:      "exponential",
+			xs:        []float64{0, 1, 2},
+			ys:        []float64{1, 2, 4},
+			x:         5,
+			model:     ForecastModelExponential,
+			wantModel: ForecastModelExponential,
+		},
+		{
+			name:      "exponential falls back to linear on zero",
+			xs:        []float64{0, 1, 2},
+			ys:        []float64{0, 0, 0},
+			x:         5,
+			model:     ForecastModelExponential,
+			wantModel: ForecastModelLinear,
+		},
+		{
+			name:    "overflowing extrapolation",
+			xs:      []float64{0, 0.001, 0.002, 0.003},
+			ys:      []float64{1, 1000, 1, 1000},
+			x:       730,
+			model:   ForecastModelExponential,
+			wantErr: ErrInsufficientHistory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prediction, err := predictTrend(tt.xs, tt.ys, tt.x, tt.model)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("predictTrend() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("predictTrend() error = %v", err)
+			}
+			if prediction.Model != tt.wantModel {
+				t.Errorf("predictTrend() model = %q, want %q", prediction.Model, tt.wantModel)
+			}
+			if prediction.Current != tt.ys[len(tt.ys)-1] {
+				t.Errorf("predictTrend() current = %v, want %v", prediction.Current, tt.ys[len(tt.ys)-1])
+			}
+		})
+	}
+}
+
+func TestThresholdStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		prediction ForecastPrediction
+		threshold  float64
+		want       string
+	}{
+		{"already above", ForecastPrediction{Current: 1600, Predicted: 1700, Lower: 1650}, 1500, ForecastStatusAbove},
+		{"exactly at threshold", ForecastPrediction{Current: 1500, Predicted: 1500, Lower: 1400}, 1500, ForecastStatusAbove},
+		{"lower bound crosses", ForecastPrediction{Current: 1400, Predicted: 1600, Lower: 1550}, 1500, ForecastStatusOnTrack},
+		{"only prediction crosses", ForecastPrediction{Current: 1400, Predicted: 1520, Lower: 1450}, 1500, ForecastStatusPossible},
+		{"falls short", ForecastPrediction{Current: 1400, Predicted: 1450, Lower: 1420}, 1500, ForecastStatusOffTrack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholdStatus(tt.prediction, tt.threshold); got != tt.want {
+				t.Errorf("thresholdStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// jsonRecords streams student objects from a JSON array or an NDJSON file and
+// presents them as rows. The union of the keys of all objects becomes the
+// header, so header aliases and column mappings apply to JSON exactly as to
+// spreadsheets and a key missing from some objects is just an empty cell.
+// Rows are numbered as in a CSV file with that header, the first object
+// being row 2.
+type jsonRecords struct {
+	file    *os.File
+	decoder *json.Decoder
+	array   bool
+	keys    []string
+	started bool
+	row     int
+}
+
+func openJSONRecords(path string) (*jsonRecords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &jsonRecords{file: f}
+	if err := r.rewind(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// rewind starts decoding from the beginning of the file
+func (r *jsonRecords) rewind() error {
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	buffered := bufio.NewReader(r.file)
+	first, err := firstByte(buffered)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	r.decoder = json.NewDecoder(buffered)
+	r.decoder.UseNumber()
+	r.array = first == '['
+	r.row = 1
+	if r.array {
+		if _, err := r.decoder.Token(); err != nil {
+			return fmt.Errorf("invalid JSON array: %w", err)
+		}
+	}
+	return nil
+}
+
+// Next returns the header on the first call and one object per call after
+// that. The header needs every object's keys, so the first call reads the
+// whole file once before rewinding it.
+func (r *jsonRecords) Next() ([]string, error) {
+	if !r.started {
+		r.started = true
+		return r.header()
+	}
+
+	object, err := r.decode()
+	if err != nil {
+		return nil, err
+	}
+	r.row++
+	record := make([]string, len(r.keys))
+	for i, key := range r.keys {
+		record[i] = jsonValue(object[key])
+	}
+	return record, nil
+}
+
+// header collects the sorted union of the keys of all objects
+func (r *jsonRecords) header() ([]string, error) {
+	seen := make(map[string]bool)
+	objects := 0
+	for {
+		object, err := r.decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.row++
+		objects++
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				r.keys = append(r.keys, key)
+			}
+		}
+	}
+	if objects == 0 {
+		return nil, io.EOF
+	}
+	sort.Strings(r.keys)
+
+	if err := r.rewind(); err != nil {
+		return nil, err
+	}
+	return r.keys, nil
+}
+
+// Row is the position of the last object as a CSV row, starting at 2
+func (r *jsonRecords) Row() int {
+	return r.row
+}
+
+func (r *jsonRecords) Close() error {
+	return r.file.Close()
+}
+
+func (r *jsonRecords) decode() (map[string]interface{}, error) {
+	if r.array && !r.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var object map[string]interface{}
+	if err := r.decoder.Decode(&object); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("row %d: invalid JSON object: %w", r.row+1, err)
+	}
+	return object, nil
+}
+
+// jsonValue renders a decoded JSON value as a cell
+func jsonValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// firstByte peeks at the first non-whitespace byte of a reader
+func firstByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.ReadByte()
+		case 0xEF:
+			// Skip a UTF-8 byte order mark
+			r.Discard(3)
+		default:
+			return b[0], nil
+		}
+	}
+}
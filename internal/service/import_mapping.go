@@ -0,0 +1,246 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ayush/ORBIT/internal/models"
+)
+
+// Student fields that can be read from an import file
+const (
+	FieldStudentID   = "student_id"
+	FieldName        = "name"
+	FieldEmail       = "email"
+	FieldLeetcodeID  = "leetcode_id"
+	FieldPassingYear = "passing_year"
+	FieldBatch       = "batch"
+	FieldDepartment  = "department"
+)
+
+// importFields lists every importable field; all of them are required
+var importFields = []string{
+	FieldStudentID,
+	FieldName,
+	FieldEmail,
+	FieldLeetcodeID,
+	FieldPassingYear,
+	FieldBatch,
+	FieldDepartment,
+}
+
+// ColumnAliases lists the header names accepted for each student field.
+// Headers are compared case-insensitively, ignoring spaces and punctuation.
+type ColumnAliases map[string][]string
+
+// DefaultColumnAliases returns the header names recognised out of the box
+func DefaultColumnAliases() ColumnAliases {
+	return ColumnAliases{
+		FieldStudentID:   {"student_id", "student id", "roll no", "roll number", "enrollment no", "enrollment number", "university roll no", "id"},
+		FieldName:        {"name", "student name", "full name"},
+		FieldEmail:       {"email", "email id", "email address", "mail"},
+		FieldLeetcodeID:  {"leetcode_id", "leetcode id", "leetcode", "leetcode username", "leetcode handle", "leetcode profile"},
+		FieldPassingYear: {"passing_year", "passing year", "graduation year", "year of passing", "pass out year"},
+		FieldBatch:       {"batch", "section", "class"},
+		FieldDepartment:  {"department", "dept", "branch", "course"},
+	}
+}
+
+// LoadColumnAliases reads extra aliases from a JSON file of the form
+// {"leetcode_id": ["LC Handle"]} and merges them with the defaults. An empty
+// path returns the defaults.
+func LoadColumnAliases(path string) (ColumnAliases, error) {
+	if path == "" {
+		return DefaultColumnAliases(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column aliases: %w", err)
+	}
+
+	var extra ColumnAliases
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("failed to parse column aliases: %w", err)
+	}
+
+	aliases := DefaultColumnAliases()
+	for field, names := range extra {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown field in column aliases: %s", field)
+		}
+		aliases[field] = append(names, aliases[field]...)
+	}
+	return aliases, nil
+}
+
+// ColumnMapping is the optional mapping sent along with an upload. Columns maps
+// a field to the exact header used in the file; Defaults supplies a value for
+// fields the file does not contain at all, such as a single batch per sheet.
+type ColumnMapping struct {
+	Columns  map[string]string `json:"columns"`
+	Defaults map[string]string `json:"defaults"`
+}
+
+// ParseColumnMapping decodes and validates a mapping JSON document
+func ParseColumnMapping(data string) (*ColumnMapping, error) {
+	var mapping ColumnMapping
+	if err := json.Unmarshal([]byte(data), &mapping); err != nil {
+		return nil, fmt.Errorf("invalid column mapping: %w", err)
+	}
+	for field := range mapping.Columns {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown field in column mapping: %s", field)
+		}
+	}
+	for field := range mapping.Defaults {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown field in column defaults: %s", field)
+		}
+	}
+	return &mapping, nil
+}
+
+// columnLayout records where each field lives in the rows of a particular file
+type columnLayout struct {
+	index    map[string]int
+	defaults map[string]string
+}
+
+// resolveColumns matches the header row against the explicit mapping first and
+// the aliases second, failing if a required field cannot be found
+func resolveColumns(header []string, aliases ColumnAliases, mapping *ColumnMapping) (*columnLayout, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := normalizeHeader(name)
+		if _, seen := positions[key]; !seen && key != "" {
+			positions[key] = i
+		}
+	}
+
+	layout := &columnLayout{
+		index:    make(map[string]int, len(importFields)),
+		defaults: make(map[string]string),
+	}
+
+	var missing []string
+	for _, field := range importFields {
+		if mapping != nil {
+			if name, ok := mapping.Columns[field]; ok {
+				i, found := positions[normalizeHeader(name)]
+				if !found {
+					return nil, fmt.Errorf("mapped column %q for %s not found in header", name, field)
+				}
+				layout.index[field] = i
+				continue
+			}
+		}
+
+		if i, found := findAlias(positions, aliases[field]); found {
+			layout.index[field] = i
+			continue
+		}
+
+		if mapping != nil {
+			if value, ok := mapping.Defaults[field]; ok {
+				layout.defaults[field] = value
+				continue
+			}
+		}
+
+		missing = append(missing, field)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	return layout, nil
+}
+
+func findAlias(positions map[string]int, names []string) (int, bool) {
+	for _, name := range names {
+		if i, found := positions[normalizeHeader(name)]; found {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// value returns the trimmed value of a field for one row
+func (l *columnLayout) value(record []string, field string) string {
+	i, ok := l.index[field]
+	if !ok {
+		return l.defaults[field]
+	}
+	if i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// student builds a student from one row, reporting empty or malformed fields
+func (l *columnLayout) student(record []string) (models.Student, error) {
+	student := models.Student{
+		StudentID:  l.value(record, FieldStudentID),
+		Name:       l.value(record, FieldName),
+		Email:      l.value(record, FieldEmail),
+		LeetcodeID: l.value(record, FieldLeetcodeID),
+		Batch:      l.value(record, FieldBatch),
+		Department: l.value(record, FieldDepartment),
+	}
+
+	var empty []string
+	for _, field := range importFields {
+		if l.value(record, field) == "" {
+			empty = append(empty, field)
+		}
+	}
+	if len(empty) > 0 {
+		return student, fmt.Errorf("missing values for: %s", strings.Join(empty, ", "))
+	}
+
+	year, err := strconv.Atoi(l.value(record, FieldPassingYear))
+	if err != nil {
+		return student, fmt.Errorf("invalid passing_year %q", l.value(record, FieldPassingYear))
+	}
+	student.PassingYear = year
+
+	return student, nil
+}
+
+// isBlank reports whether every cell of a row is empty
+func isBlank(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHeader lowercases a header and drops everything but letters and digits
+func normalizeHeader(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
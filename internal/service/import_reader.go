@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// recordReader yields the rows of an import file one at a time, header first.
+// Next returns io.EOF after the last row; Row is the 1-based position of the
+// last row returned as the user sees it in the file.
+type recordReader interface {
+	Next() ([]string, error)
+	Row() int
+	Close() error
+}
+
+// openRecords opens a stored upload for streaming, choosing the parser by extension
+func openRecords(path, fileName string) (recordReader, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xlsx":
+		return openExcelRecords(path)
+	case ".json", ".ndjson", ".jsonl":
+		return openJSONRecords(path)
+	default:
+		return openCSVRecords(path)
+	}
+}
+
+// csvRecords reads a CSV file line by line
+type csvRecords struct {
+	file   *os.File
+	reader *csv.Reader
+	line   int
+}
+
+func openCSVRecords(path string) (*csvRecords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1 // columns are matched by header, so ragged rows are allowed
+	return &csvRecords{file: f, reader: reader}, nil
+}
+
+func (r *csvRecords) Next() ([]string, error) {
+	record, err := r.reader.Read()
+	if err == nil {
+		// Track the line the record started on, so blank lines skipped by
+		// the CSV reader do not shift row numbers
+		r.line, _ = r.reader.FieldPos(0)
+	} else {
+		r.line++
+	}
+	return record, err
+}
+
+func (r *csvRecords) Row() int {
+	return r.line
+}
+
+func (r *csvRecords) Close() error {
+	return r.file.Close()
+}
+
+// excelRecords iterates the rows of the first sheet of a workbook without
+// loading the whole sheet
+type excelRecords struct {
+	file *excelize.File
+	rows *excelize.Rows
+	row  int
+}
+
+func openExcelRecords(path string) (*excelRecords, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("no sheets found in excel file")
+	}
+
+	rows, err := f.Rows(sheets[0])
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &excelRecords{file: f, rows: rows}, nil
+}
+
+func (r *excelRecords) Next() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.row++
+	return r.rows.Columns()
+}
+
+func (r *excelRecords) Row() int {
+	return r.row
+}
+
+func (r *excelRecords) Close() error {
+	r.rows.Close()
+	return r.file.Close()
+}
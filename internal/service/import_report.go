@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ayush/ORBIT/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaxUploadPageSize caps how many uploads one page may hold
+const MaxUploadPageSize = 100
+
+// ListUploads returns a page of uploads, newest first
+func (s *ImportService) ListUploads(ctx context.Context, page, pageSize int, status string) ([]models.FileUpload, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > MaxUploadPageSize {
+		pageSize = MaxUploadPageSize
+	}
+	return s.uploads.List(ctx, (page-1)*pageSize, pageSize, status)
+}
+
+// GetUpload returns a single upload with its status and counts
+func (s *ImportService) GetUpload(ctx context.Context, id uint) (*models.FileUpload, error) {
+	upload, err := s.uploads.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return upload, nil
+}
+
+// WriteErrorReport writes the failed rows of an upload as CSV: the original
+// header and values followed by the row number and the failure reason. When
+// the whole upload failed before any row was processed, every data row is
+// annotated with the upload's error.
+func (s *ImportService) WriteErrorReport(ctx context.Context, upload *models.FileUpload, w io.Writer) error {
+	failures := make(map[int]string)
+
+	rows, err := s.rows.ListFailedByUpload(ctx, upload.ID)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if row.Error != nil {
+			failures[row.RowNumber] = *row.Error
+		}
+	}
+
+	reader, err := openRecords(upload.StoragePath, upload.FileName)
+	if err != nil {
+		return fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	defer reader.Close()
+
+	header, err := reader.Next()
+	if err == io.EOF {
+		return fmt.Errorf("uploaded file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	uploadFailed := upload.Status == UploadStatusFailed && len(rows) == 0
+	uploadError := ""
+	if upload.ErrorDetails != nil {
+		uploadError = *upload.ErrorDetails
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(append(append([]string{}, header...), "row", "error")); err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		rowNumber := reader.Row()
+		if err != nil {
+			return fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+
+		reason, failed := failures[rowNumber]
+		if uploadFailed && !isBlank(record) {
+			reason, failed = uploadError, true
+		}
+		if !failed {
+			continue
+		}
+
+		// Pad short rows so the annotation columns line up
+		line := make([]string, len(header), len(header)+2)
+		copy(line, record)
+		line = append(line, strconv.Itoa(rowNumber), reason)
+		if err := out.Write(line); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
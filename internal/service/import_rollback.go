@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AuditActionUploadRollback is the audit action written when an import is undone
+const AuditActionUploadRollback = "upload.rollback"
+
+// ErrUploadNotRollbackable is returned for uploads that are still queued or
+// running, were already rolled back, or whose recorded rows do not cover
+// everything they wrote
+var ErrUploadNotRollbackable = errors.New("upload cannot be rolled back")
+
+// RollbackResult summarises what a rollback undid and which rows it left alone
+type RollbackResult struct {
+	FileUploadID uint           `json:"file_upload_id"`
+	Deleted      int            `json:"deleted"`
+	Restored     int            `json:"restored"`
+	Skipped      []RollbackSkip `json:"skipped"`
+}
+
+// RollbackSkip is an imported row a rollback did not undo
+type RollbackSkip struct {
+	RowNumber int    `json:"row_number"`
+	StudentID string `json:"student_id"`
+	Reason    string `json:"reason"`
+}
+
+// checkRecordedRows makes sure every student the upload reports as created or
+// updated has a recorded row, so a rollback cannot silently leave some of
+// them in place
+func checkRecordedRows(upload *models.FileUpload, applied []models.ImportRow) error {
+	created, updated := 0, 0
+	for _, row := range applied {
+		switch row.Outcome {
+		case models.ImportRowCreated:
+			created++
+		case models.ImportRowUpdated:
+			updated++
+		}
+	}
+	if created != upload.CreatedRecords || updated != upload.UpdatedRecords {
+		return fmt.Errorf("%w: the upload created %d and updated %d students but %d and %d are recorded",
+			ErrUploadNotRollbackable, upload.CreatedRecords, upload.UpdatedRecords, created, updated)
+	}
+	return nil
+}
+
+// RollbackUpload reverts what an import wrote in a single transaction:
+// students it created are deleted and students it updated get their previous
+// values back. Students changed since the import, by hand or by a later
+// import, keep their current values; those rows are skipped and reported.
+// Either everything else is undone or nothing is.
+func (s *ImportService) RollbackUpload(ctx context.Context, uploadID uint, actor string) (*RollbackResult, error) {
+	logger := s.logger.With(
+		zap.String("service", "ImportService"),
+		zap.Uint("file_upload_id", uploadID),
+		zap.String("actor", actor),
+	)
+
+	result := &RollbackResult{FileUploadID: uploadID, Skipped: []RollbackSkip{}}
+	// Student hooks run once the transaction has committed
+	type change struct {
+		studentID     uint
+		before, after *models.StudentSnapshot
+	}
+	var changes []change
+	err := s.students.repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		uploads := database.NewFileUploadDB(tx)
+		rows := database.NewImportRowDB(tx)
+		students := repository.NewStudentRepository(tx)
+		audit := database.NewAuditLogDB(tx)
+
+		upload, err := uploads.LockByID(ctx, uploadID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		switch upload.Status {
+		case UploadStatusPending, UploadStatusProcessing, UploadStatusRolledBack:
+			return fmt.Errorf("%w: status is %s", ErrUploadNotRollbackable, upload.Status)
+		}
+
+		applied, err := rows.ListAppliedByUpload(ctx, uploadID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecordedRows(upload, applied); err != nil {
+			return err
+		}
+
+		for _, row := range applied {
+			if row.StudentRefID == nil {
+				continue
+			}
+			skip := func(reason string) {
+				result.Skipped = append(result.Skipped, RollbackSkip{
+					RowNumber: row.RowNumber,
+					StudentID: row.StudentID,
+					Reason:    reason,
+				})
+			}
+
+			current, err := students.LockByID(ctx, *row.StudentRefID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					skip("student no longer exists")
+					continue
+				}
+				return fmt.Errorf("row %d: failed to lock student: %w", row.RowNumber, err)
+			}
+			if row.Applied == nil {
+				skip("values written by the import were not recorded")
+				continue
+			}
+			var written models.StudentSnapshot
+			if err := json.Unmarshal([]byte(*row.Applied), &written); err != nil {
+				return fmt.Errorf("row %d: invalid snapshot: %w", row.RowNumber, err)
+			}
+			if snapshotOf(current) != written {
+				skip("student changed since the import")
+				continue
+			}
+
+			switch row.Outcome {
+			case models.ImportRowCreated:
+				if err := students.Delete(ctx, *row.StudentRefID); err != nil {
+					return fmt.Errorf("row %d: failed to delete student: %w", row.RowNumber, err)
+				}
+				changes = append(changes, change{*row.StudentRefID, &written, nil})
+				result.Deleted++
+			case models.ImportRowUpdated:
+				if row.Previous == nil {
+					continue
+				}
+				var snapshot models.StudentSnapshot
+				if err := json.Unmarshal([]byte(*row.Previous), &snapshot); err != nil {
+					return fmt.Errorf("row %d: invalid snapshot: %w", row.RowNumber, err)
+				}
+				if err := students.RestoreSnapshot(ctx, *row.StudentRefID, snapshot); err != nil {
+					return fmt.Errorf("row %d: failed to restore student: %w", row.RowNumber, err)
+				}
+				changes = append(changes, change{*row.StudentRefID, &written, &snapshot})
+				result.Restored++
+			}
+		}
+
+		now := time.Now()
+		upload.Status = UploadStatusRolledBack
+		upload.RolledBackAt = &now
+		upload.UpdatedAt = now
+		if err := uploads.Update(ctx, upload); err != nil {
+			return fmt.Errorf("failed to update file upload: %w", err)
+		}
+
+		details, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return audit.Create(ctx, &models.AuditLog{
+			Action:     AuditActionUploadRollback,
+			EntityType: "file_upload",
+			EntityID:   uploadID,
+			Actor:      actor,
+			Details:    string(details),
+		})
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrUploadNotRollbackable) {
+			logger.Error("Failed to roll back upload", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	for _, c := range changes {
+		s.students.studentChanged(ctx, c.studentID, c.before, c.after)
+	}
+
+	logger.Info("Upload rolled back",
+		zap.Int("deleted", result.Deleted),
+		zap.Int("restored", result.Restored),
+		zap.Int("skipped", len(result.Skipped)),
+	)
+	return result, nil
+}
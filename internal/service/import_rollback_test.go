@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ayush/ORBIT/internal/models"
+)
+
+func importRow(rowNumber int, outcome string) models.ImportRow {
+	return models.ImportRow{FileUploadID: 1, RowNumber: rowNumber, Outcome: outcome}
+}
+
+func appliedRows(rows []models.ImportRow) []models.ImportRow {
+	var applied []models.ImportRow
+	for _, row := range rows {
+		if row.Outcome == models.ImportRowCreated || row.Outcome == models.ImportRowUpdated {
+			applied = append(applied, row)
+		}
+	}
+	return applied
+}
+
+// TestRollbackAfterRetry runs an upload whose job dies after its first batch
+// committed, retries it and checks the rollback covers every student it wrote
+func TestRollbackAfterRetry(t *testing.T) {
+	firstBatch := []models.ImportRow{
+		importRow(2, models.ImportRowCreated),
+		importRow(3, models.ImportRowCreated),
+		importRow(4, models.ImportRowUpdated),
+	}
+	secondBatch := []models.ImportRow{
+		importRow(5, models.ImportRowCreated),
+		importRow(6, models.ImportRowFailed),
+	}
+	upload := &models.FileUpload{}
+
+	// The first attempt commits a batch, then the worker dies
+	progress, _ := resumedProgress(upload, nil)
+	for i := range firstBatch {
+		progress.total++
+		progress.record(upload, &firstBatch[i])
+	}
+	stored := append([]models.ImportRow{}, firstBatch...)
+
+	// The retry starts from the recorded rows and writes only the rest
+	progress, recorded := resumedProgress(upload, stored)
+	if progress.total != len(firstBatch) {
+		t.Fatalf("resumed %d rows, want %d", progress.total, len(firstBatch))
+	}
+	for i := range secondBatch {
+		if recorded[secondBatch[i].RowNumber] {
+			t.Fatalf("row %d resumed before it was written", secondBatch[i].RowNumber)
+		}
+		progress.total++
+		progress.record(upload, &secondBatch[i])
+		stored = append(stored, secondBatch[i])
+	}
+	for _, row := range firstBatch {
+		if !recorded[row.RowNumber] {
+			t.Errorf("row %d written again by the retry", row.RowNumber)
+		}
+	}
+	if upload.CreatedRecords != 3 || upload.UpdatedRecords != 1 {
+		t.Errorf("upload counts %d created, %d updated, want 3 and 1", upload.CreatedRecords, upload.UpdatedRecords)
+	}
+
+	tests := []struct {
+		name    string
+		applied []models.ImportRow
+		wantErr bool
+	}{
+		{
+			name:    "every written student recorded",
+			applied: appliedRows(stored),
+		},
+		{
+			name:    "first attempt's rows missing",
+			applied: appliedRows(secondBatch),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRecordedRows(upload, tt.applied)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUploadNotRollbackable) {
+					t.Fatalf("checkRecordedRows() error = %v, want %v", err, ErrUploadNotRollbackable)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkRecordedRows() error = %v", err)
+			}
+		})
+	}
+}
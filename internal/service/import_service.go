@@ -0,0 +1,629 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/leetcode"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// File upload statuses
+const (
+	UploadStatusPending             = "pending"
+	UploadStatusProcessing          = "processing"
+	UploadStatusCompleted           = "completed"
+	UploadStatusCompletedWithErrors = "completed_with_errors"
+	UploadStatusFailed              = "failed"
+	UploadStatusRolledBack          = "rolled_back"
+)
+
+// Import modes decide what happens to rows whose student_id already exists
+const (
+	ImportModeInsert     = "insert"      // every row creates a student; existing ones fail
+	ImportModeUpsert     = "upsert"      // existing students are updated, new ones created
+	ImportModeUpdateOnly = "update_only" // existing students are updated, unknown ones fail
+)
+
+// ParseImportMode validates an import mode, defaulting to insert
+func ParseImportMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ImportModeInsert, nil
+	case ImportModeInsert, ImportModeUpsert, ImportModeUpdateOnly:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid import mode %q: must be one of %s, %s, %s",
+			mode, ImportModeInsert, ImportModeUpsert, ImportModeUpdateOnly)
+	}
+}
+
+// ImportService turns uploaded student rosters into student records
+type ImportService struct {
+	students *StudentService
+	uploads  *database.FileUploadDB
+	rows     *database.ImportRowDB
+	aliases  ColumnAliases
+	verifier *LeetCodeVerifier
+	logger   *zap.Logger
+}
+
+// NewImportService creates an import service. Without a verifier, rows that
+// ask for verification are flagged as unverified.
+func NewImportService(students *StudentService, aliases ColumnAliases, verifier *LeetCodeVerifier, logger *zap.Logger) *ImportService {
+	return &ImportService{
+		students: students,
+		uploads:  database.NewFileUploadDB(students.repo.DB),
+		rows:     database.NewImportRowDB(students.repo.DB),
+		aliases:  aliases,
+		verifier: verifier,
+		logger:   logger,
+	}
+}
+
+// importBatchSize is the number of rows written per transaction
+const importBatchSize = 500
+
+// maxErrorDetails caps the failures copied into FileUpload.ErrorDetails; the
+// complete list is available from the upload's error report
+const maxErrorDetails = 100
+
+// pendingRow is a parsed row waiting to be written with the rest of its batch
+type pendingRow struct {
+	student models.Student
+	result  models.ImportRow
+	profile *leetcode.UserProfile
+}
+
+// importProgress accumulates the outcome of an upload across batches
+type importProgress struct {
+	counts map[string]int
+	total  int
+	errors []string
+}
+
+// ProcessUpload streams the stored file of an upload and applies its rows in
+// batches according to the upload's import mode, recording the outcome of
+// every row. Each batch is written in its own transaction together with its
+// row results, so a retried job resumes after the rows already recorded.
+func (s *ImportService) ProcessUpload(ctx context.Context, uploadID uint) error {
+	start := time.Now()
+	fileUpload, err := s.uploads.GetByID(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	if fileUpload.Status == UploadStatusRolledBack {
+		return nil
+	}
+
+	mode, err := ParseImportMode(fileUpload.ImportMode)
+	if err != nil {
+		s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+		return nil
+	}
+	policy, err := ParseVerifyPolicy(fileUpload.VerifyPolicy)
+	if err != nil {
+		s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+		return nil
+	}
+
+	logger := s.logger.With(
+		zap.String("service", "ImportService"),
+		zap.Uint("file_upload_id", fileUpload.ID),
+		zap.String("filename", fileUpload.FileName),
+		zap.String("mode", mode),
+		zap.String("verify", policy),
+	)
+
+	// A retried job keeps what earlier attempts committed, so those students
+	// stay recorded for rollback, and carries on after them
+	progress, recorded, err := s.resumeProgress(ctx, fileUpload)
+	if err != nil {
+		return err
+	}
+	logger.Info("Starting file processing", zap.Int("resumed_rows", len(recorded)))
+	s.updateStatus(ctx, fileUpload, UploadStatusProcessing, "",
+		progress.total, progress.total-progress.counts[models.ImportRowFailed], progress.counts[models.ImportRowFailed])
+
+	reader, err := openRecords(fileUpload.StoragePath, fileUpload.FileName)
+	if err != nil {
+		logger.Error("Failed to read file",
+			zap.Error(err),
+		)
+		s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+		return nil
+	}
+	defer reader.Close()
+
+	header, err := reader.Next()
+	if err != nil {
+		if err == io.EOF {
+			logger.Warn("Empty file")
+			s.updateStatus(ctx, fileUpload, UploadStatusFailed, "File is empty or has no data rows", 0, 0, 0)
+		} else {
+			s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+		}
+		return nil
+	}
+
+	var mapping *ColumnMapping
+	if fileUpload.ColumnMapping != nil {
+		mapping, err = ParseColumnMapping(*fileUpload.ColumnMapping)
+		if err != nil {
+			s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+			return nil
+		}
+	}
+
+	layout, err := resolveColumns(header, s.aliases, mapping)
+	if err != nil {
+		logger.Warn("Could not map header row",
+			zap.Error(err),
+			zap.Strings("header", header),
+		)
+		s.updateStatus(ctx, fileUpload, UploadStatusFailed, err.Error(), 0, 0, 0)
+		return nil
+	}
+
+	batch := make([]pendingRow, 0, importBatchSize)
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		rowNumber := reader.Row()
+		if err != nil {
+			logger.Error("Failed to read row",
+				zap.Int("row_number", rowNumber),
+				zap.Error(err),
+			)
+			s.finishBatch(ctx, logger, mode, fileUpload, batch, progress)
+			s.updateStatus(ctx, fileUpload, UploadStatusFailed,
+				fmt.Sprintf("Row %d: %s", rowNumber, err.Error()),
+				progress.total, progress.total-progress.counts[models.ImportRowFailed], progress.counts[models.ImportRowFailed])
+			return nil
+		}
+		if isBlank(record) || recorded[rowNumber] {
+			continue
+		}
+		progress.total++
+
+		row := pendingRow{result: models.ImportRow{
+			FileUploadID: fileUpload.ID,
+			RowNumber:    rowNumber,
+		}}
+
+		row.student, err = layout.student(record)
+		if err == nil {
+			err = validateImportedStudent(&row.student)
+		}
+		row.result.StudentID = row.student.StudentID
+		if err == nil && policy != VerifyOff {
+			row.profile, row.result.Warning, err = s.verifyHandle(ctx, policy, row.student.LeetcodeID)
+		}
+		if err != nil {
+			failRow(&row.result, err)
+		}
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			s.finishBatch(ctx, logger, mode, fileUpload, batch, progress)
+			batch = batch[:0]
+			s.updateStatus(ctx, fileUpload, UploadStatusProcessing, "",
+				progress.total, progress.total-progress.counts[models.ImportRowFailed], progress.counts[models.ImportRowFailed])
+		}
+	}
+	s.finishBatch(ctx, logger, mode, fileUpload, batch, progress)
+
+	if progress.total == 0 {
+		logger.Warn("No data rows")
+		s.updateStatus(ctx, fileUpload, UploadStatusFailed, "File is empty or has no data rows", 0, 0, 0)
+		return nil
+	}
+
+	failed := progress.counts[models.ImportRowFailed]
+	successful := progress.total - failed
+
+	status := UploadStatusCompleted
+	if failed > 0 {
+		status = UploadStatusCompletedWithErrors
+	}
+
+	duration := time.Since(start)
+	logger.Info("File processing completed",
+		zap.Duration("duration", duration),
+		zap.String("status", status),
+		zap.Int("created", fileUpload.CreatedRecords),
+		zap.Int("updated", fileUpload.UpdatedRecords),
+		zap.Int("unchanged", fileUpload.UnchangedRecords),
+		zap.Int("flagged", fileUpload.FlaggedRecords),
+		zap.Int("failed", failed),
+		zap.Int("total_processed", progress.total),
+	)
+
+	errorDetails := strings.Join(progress.errors, "\n")
+	if failed > len(progress.errors) {
+		errorDetails += fmt.Sprintf("\n... and %d more; see the error report", failed-len(progress.errors))
+	}
+	s.updateStatus(ctx, fileUpload, status, errorDetails, progress.total, successful, failed)
+	return nil
+}
+
+// resumeProgress folds the rows recorded by earlier attempts of an upload
+// into a fresh progress and the upload's counters, and returns their row
+// numbers
+func (s *ImportService) resumeProgress(ctx context.Context, fileUpload *models.FileUpload) (*importProgress, map[int]bool, error) {
+	rows, err := s.rows.ListByUpload(ctx, fileUpload.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	progress, recorded := resumedProgress(fileUpload, rows)
+	return progress, recorded, nil
+}
+
+// resumedProgress starts the progress of an upload from its recorded rows
+func resumedProgress(fileUpload *models.FileUpload, rows []models.ImportRow) (*importProgress, map[int]bool) {
+	progress := &importProgress{counts: make(map[string]int)}
+	recorded := make(map[int]bool, len(rows))
+	fileUpload.CreatedRecords, fileUpload.UpdatedRecords, fileUpload.UnchangedRecords = 0, 0, 0
+	fileUpload.FlaggedRecords = 0
+	for i := range rows {
+		recorded[rows[i].RowNumber] = true
+		progress.total++
+		progress.record(fileUpload, &rows[i])
+	}
+	return progress, recorded
+}
+
+// record folds a stored row result into the progress and the upload's counters
+func (p *importProgress) record(fileUpload *models.FileUpload, result *models.ImportRow) {
+	// Flagged rows only count once they are stored
+	if result.Warning != nil && result.Outcome != models.ImportRowFailed {
+		fileUpload.FlaggedRecords++
+	}
+	if result.Error != nil && len(p.errors) < maxErrorDetails {
+		p.errors = append(p.errors, fmt.Sprintf("Row %d: %s", result.RowNumber, *result.Error))
+	}
+	p.counts[result.Outcome]++
+
+	fileUpload.CreatedRecords = p.counts[models.ImportRowCreated]
+	fileUpload.UpdatedRecords = p.counts[models.ImportRowUpdated]
+	fileUpload.UnchangedRecords = p.counts[models.ImportRowUnchanged]
+}
+
+// finishBatch writes a batch, seeds stats for verified rows, stores the row
+// results and folds them into the upload's counters
+func (s *ImportService) finishBatch(ctx context.Context, logger *zap.Logger, mode string, fileUpload *models.FileUpload, batch []pendingRow, progress *importProgress) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.writeBatch(ctx, mode, batch); err != nil {
+		// One bad row aborts the whole transaction, so redo the batch row by
+		// row to find out which rows fail. Each row is stored with its result.
+		logger.Warn("Batch write failed, retrying row by row",
+			zap.Int("first_row", batch[0].result.RowNumber),
+			zap.Error(err),
+		)
+		for i := range batch {
+			row := &batch[i]
+			if row.result.Outcome != models.ImportRowFailed {
+				err := s.writeRow(ctx, mode, row)
+				if err == nil {
+					continue
+				}
+				row.result.Outcome, row.result.StudentRefID, row.result.Previous, row.result.Applied = "", nil, nil, nil
+				failRow(&row.result, err)
+			}
+			if err := s.rows.CreateBatch(ctx, []models.ImportRow{row.result}); err != nil {
+				logger.Error("Failed to save row result",
+					zap.Int("row_number", row.result.RowNumber),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	for i := range batch {
+		row := &batch[i]
+		if row.profile != nil && row.result.Outcome != models.ImportRowFailed {
+			s.seedStats(ctx, logger.With(zap.Int("row_number", row.result.RowNumber)), *row.result.StudentRefID, row.profile)
+		}
+		if row.result.Outcome == models.ImportRowUpdated {
+			s.announceUpdate(ctx, logger, &row.result)
+		}
+		progress.record(fileUpload, &row.result)
+	}
+}
+
+// announceUpdate runs the student hooks for a committed update with the
+// values it replaced and wrote
+func (s *ImportService) announceUpdate(ctx context.Context, logger *zap.Logger, result *models.ImportRow) {
+	if result.StudentRefID == nil || result.Previous == nil || result.Applied == nil {
+		return
+	}
+	var before, after models.StudentSnapshot
+	if err := json.Unmarshal([]byte(*result.Previous), &before); err != nil {
+		logger.Warn("Failed to decode replaced values", zap.Int("row_number", result.RowNumber), zap.Error(err))
+		return
+	}
+	if err := json.Unmarshal([]byte(*result.Applied), &after); err != nil {
+		logger.Warn("Failed to decode written values", zap.Int("row_number", result.RowNumber), zap.Error(err))
+		return
+	}
+	s.students.studentChanged(ctx, *result.StudentRefID, &before, &after)
+}
+
+// writeBatch applies the valid rows of a batch and stores the results of all
+// of its rows in one transaction. Existing students are looked up in a single
+// query and new ones are inserted together.
+func (s *ImportService) writeBatch(ctx context.Context, mode string, batch []pendingRow) error {
+	return s.students.repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		students := repository.NewStudentRepository(tx)
+
+		existing := make(map[string]*models.Student)
+		if mode != ImportModeInsert {
+			ids := make([]string, 0, len(batch))
+			for i := range batch {
+				if batch[i].result.Outcome != models.ImportRowFailed {
+					ids = append(ids, batch[i].student.StudentID)
+				}
+			}
+			found, err := students.ListByStudentIDs(ctx, ids)
+			if err != nil {
+				return fmt.Errorf("failed to look up students: %w", err)
+			}
+			for i := range found {
+				existing[found[i].StudentID] = &found[i]
+			}
+		}
+
+		var creates []*pendingRow
+		for i := range batch {
+			row := &batch[i]
+			if row.result.Outcome == models.ImportRowFailed {
+				continue
+			}
+			if current, ok := existing[row.student.StudentID]; ok {
+				if err := updateExisting(ctx, students, current, &row.student, &row.result); err != nil {
+					return err
+				}
+				continue
+			}
+			if mode == ImportModeUpdateOnly {
+				failRow(&row.result, fmt.Errorf("student_id %q not found", row.student.StudentID))
+				continue
+			}
+			creates = append(creates, row)
+		}
+
+		if len(creates) > 0 {
+			newStudents := make([]*models.Student, len(creates))
+			for i, row := range creates {
+				newStudents[i] = &row.student
+			}
+			if err := students.CreateInBatches(ctx, newStudents, importBatchSize); err != nil {
+				return fmt.Errorf("failed to create students: %w", err)
+			}
+			for _, row := range creates {
+				applied, err := snapshotJSON(&row.student)
+				if err != nil {
+					return err
+				}
+				row.result.Outcome = models.ImportRowCreated
+				row.result.StudentRefID = &row.student.ID
+				row.result.Applied = &applied
+			}
+		}
+
+		return database.NewImportRowDB(tx).CreateBatch(ctx, results(batch))
+	})
+}
+
+// writeRow applies a single row and stores its result in one transaction
+func (s *ImportService) writeRow(ctx context.Context, mode string, row *pendingRow) error {
+	row.result.Outcome, row.result.StudentRefID, row.result.Previous, row.result.Applied = "", nil, nil, nil
+	row.student.ID = 0
+	return s.students.repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.applyRow(ctx, repository.NewStudentRepository(tx), mode, &row.student, &row.result); err != nil {
+			return err
+		}
+		return database.NewImportRowDB(tx).CreateBatch(ctx, []models.ImportRow{row.result})
+	})
+}
+
+// applyRow writes one imported student according to the mode and records the
+// outcome, the affected student, the values written and, for updates, the
+// values they replaced
+func (s *ImportService) applyRow(ctx context.Context, students *repository.StudentRepository, mode string, student *models.Student, result *models.ImportRow) error {
+	if mode != ImportModeInsert {
+		existing, err := students.GetByStudentID(ctx, student.StudentID)
+		switch {
+		case err == nil:
+			return updateExisting(ctx, students, existing, student, result)
+		case !errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("failed to look up student: %w", err)
+		case mode == ImportModeUpdateOnly:
+			return fmt.Errorf("student_id %q not found", student.StudentID)
+		}
+	}
+
+	if err := students.Create(ctx, student); err != nil {
+		return fmt.Errorf("failed to create student: %w", err)
+	}
+	applied, err := snapshotJSON(student)
+	if err != nil {
+		return err
+	}
+	result.Outcome = models.ImportRowCreated
+	result.StudentRefID = &student.ID
+	result.Applied = &applied
+	return nil
+}
+
+// updateExisting merges an imported row into an existing student, saving it
+// only when something changed and keeping the replaced and written values
+// for rollback
+func updateExisting(ctx context.Context, students *repository.StudentRepository, existing, imported *models.Student, result *models.ImportRow) error {
+	result.StudentRefID = &existing.ID
+	previous, err := snapshotJSON(existing)
+	if err != nil {
+		return err
+	}
+	if !mergeStudent(existing, imported) {
+		result.Outcome = models.ImportRowUnchanged
+		return nil
+	}
+	if err := students.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update student: %w", err)
+	}
+	applied, err := snapshotJSON(existing)
+	if err != nil {
+		return err
+	}
+	result.Outcome = models.ImportRowUpdated
+	result.Previous = &previous
+	result.Applied = &applied
+	return nil
+}
+
+// failRow marks a row result as failed with the given reason
+func failRow(result *models.ImportRow, err error) {
+	msg := err.Error()
+	result.Outcome = models.ImportRowFailed
+	result.Error = &msg
+}
+
+// results returns the row results of a batch
+func results(batch []pendingRow) []models.ImportRow {
+	out := make([]models.ImportRow, len(batch))
+	for i := range batch {
+		out[i] = batch[i].result
+	}
+	return out
+}
+
+// snapshotOf captures the importable fields of a student
+func snapshotOf(student *models.Student) models.StudentSnapshot {
+	return models.StudentSnapshot{
+		Name:        student.Name,
+		Email:       student.Email,
+		LeetcodeID:  student.LeetcodeID,
+		PassingYear: student.PassingYear,
+		Batch:       student.Batch,
+		Department:  student.Department,
+	}
+}
+
+// snapshotJSON encodes the importable fields of a student for an import row
+func snapshotJSON(student *models.Student) (string, error) {
+	data, err := json.Marshal(snapshotOf(student))
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot student: %w", err)
+	}
+	return string(data), nil
+}
+
+// verifyHandle looks up the LeetCode ID of a row. An unknown handle fails the
+// row or only flags it depending on the policy; a lookup that could not be made
+// flags the row rather than failing it.
+func (s *ImportService) verifyHandle(ctx context.Context, policy, handle string) (*leetcode.UserProfile, *string, error) {
+	if s.verifier == nil {
+		warning := "leetcode id not verified: verification unavailable"
+		return nil, &warning, nil
+	}
+
+	profile, err := s.verifier.Verify(ctx, handle)
+	switch {
+	case err == nil:
+		return profile, nil, nil
+	case errors.Is(err, leetcode.ErrUserNotFound):
+		msg := fmt.Sprintf("leetcode user %q not found", handle)
+		if policy == VerifyFail {
+			return nil, nil, errors.New(msg)
+		}
+		return nil, &msg, nil
+	case ctx.Err() != nil:
+		return nil, nil, ctx.Err()
+	default:
+		warning := fmt.Sprintf("leetcode id not verified: %v", err)
+		return nil, &warning, nil
+	}
+}
+
+// seedStats stores the verified profile as the student's first stats snapshot
+// unless the student already has one
+func (s *ImportService) seedStats(ctx context.Context, logger *zap.Logger, studentID uint, profile *leetcode.UserProfile) {
+	_, err := s.students.repo.GetLeetCodeStatsByStudentID(ctx, studentID)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		logger.Warn("Failed to check existing stats", zap.Error(err))
+		return
+	}
+
+	if err := s.students.repo.UpsertLeetCodeStats(ctx, statsFromProfile(studentID, profile)); err != nil {
+		logger.Warn("Failed to seed leetcode stats", zap.Error(err))
+	}
+}
+
+// mergeStudent copies the imported fields onto an existing student and reports
+// whether any of them changed
+func mergeStudent(existing, imported *models.Student) bool {
+	changed := existing.Name != imported.Name ||
+		existing.Email != imported.Email ||
+		existing.LeetcodeID != imported.LeetcodeID ||
+		existing.PassingYear != imported.PassingYear ||
+		existing.Batch != imported.Batch ||
+		existing.Department != imported.Department
+
+	existing.Name = imported.Name
+	existing.Email = imported.Email
+	existing.LeetcodeID = imported.LeetcodeID
+	existing.PassingYear = imported.PassingYear
+	existing.Batch = imported.Batch
+	existing.Department = imported.Department
+	return changed
+}
+
+// MarkFailed records that an upload could not be processed at all
+func (s *ImportService) MarkFailed(ctx context.Context, uploadID uint, reason string) error {
+	fileUpload, err := s.uploads.GetByID(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	s.updateStatus(ctx, fileUpload, UploadStatusFailed, reason, fileUpload.TotalRecords, fileUpload.SuccessfulRecords, fileUpload.FailedRecords)
+	return nil
+}
+
+func (s *ImportService) updateStatus(ctx context.Context, fileUpload *models.FileUpload, status, errorDetails string, total, successful, failed int) {
+	fileUpload.Status = status
+	fileUpload.TotalRecords = total
+	fileUpload.SuccessfulRecords = successful
+	fileUpload.FailedRecords = failed
+	if errorDetails != "" {
+		fileUpload.ErrorDetails = &errorDetails
+	}
+	if status != UploadStatusProcessing {
+		fileUpload.ProcessedAt = time.Now()
+	}
+	fileUpload.UpdatedAt = time.Now()
+
+	if err := s.uploads.Update(ctx, fileUpload); err != nil {
+		s.logger.Error("Failed to update file upload status",
+			zap.Uint("file_upload_id", fileUpload.ID),
+			zap.Error(err),
+		)
+	}
+}
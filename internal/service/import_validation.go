@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/models"
+)
+
+// Passing years accepted on import, relative to the current year
+const (
+	passingYearsBack  = 1
+	passingYearsAhead = 6
+)
+
+// identifierLookupChunk bounds the number of values per IN clause when checking
+// a file against existing students
+const identifierLookupChunk = 1000
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// RowValidation is the outcome of validating a single data row
+type RowValidation struct {
+	Row        int      `json:"row"`
+	StudentID  string   `json:"student_id"`
+	Email      string   `json:"email"`
+	LeetcodeID string   `json:"leetcode_id"`
+	Valid      bool     `json:"valid"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Dry runs never look up LeetCode IDs, which are limited to a few lookups a
+// minute; the report says whether the import would have verified them
+const (
+	DryRunVerificationOff        = "off"
+	DryRunVerificationUnverified = "unverified"
+)
+
+// ValidationReport summarises a dry run of an import file
+type ValidationReport struct {
+	TotalRows    int             `json:"total_rows"`
+	ValidRows    int             `json:"valid_rows"`
+	InvalidRows  int             `json:"invalid_rows"`
+	Verification string          `json:"verification"`
+	Rows         []RowValidation `json:"rows"`
+}
+
+// ValidateFile parses an import file and checks every row without writing
+// anything: required values, email format, passing year range, and duplicate
+// student IDs, emails and LeetCode IDs within the file and against the database.
+// Conflicts with existing students are judged by the import mode. LeetCode IDs
+// are not looked up; unless the verify policy is off the report marks them
+// unverified.
+func (s *ImportService) ValidateFile(ctx context.Context, path, fileName string, mapping *ColumnMapping, mode, policy string) (*ValidationReport, error) {
+	reader, err := openRecords(path, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	header, err := reader.Next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty or has no data rows")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := resolveColumns(header, s.aliases, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{Rows: make([]RowValidation, 0)}
+	students := make([]models.Student, 0)
+
+	// First row seen for each identifier, keyed by field then value
+	seen := map[string]map[string]int{
+		FieldStudentID:  {},
+		FieldEmail:      {},
+		FieldLeetcodeID: {},
+	}
+
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		rowNumber := reader.Row()
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", rowNumber, err)
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		row := RowValidation{Row: rowNumber}
+		student, err := layout.student(record)
+		row.StudentID, row.Email, row.LeetcodeID = student.StudentID, student.Email, student.LeetcodeID
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		} else if err := validateImportedStudent(&student); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+
+		for _, id := range identifiers(&student) {
+			if id.value == "" {
+				continue
+			}
+			if first, dup := seen[id.field][id.value]; dup {
+				row.Errors = append(row.Errors, fmt.Sprintf("duplicate %s %q (also on row %d)", id.field, id.value, first))
+				continue
+			}
+			seen[id.field][id.value] = row.Row
+		}
+
+		report.Rows = append(report.Rows, row)
+		students = append(students, student)
+	}
+	if len(report.Rows) == 0 {
+		return nil, fmt.Errorf("file is empty or has no data rows")
+	}
+
+	if err := s.checkExisting(ctx, report, students, mode); err != nil {
+		return nil, err
+	}
+
+	report.Verification = DryRunVerificationOff
+	if policy != VerifyOff {
+		report.Verification = DryRunVerificationUnverified
+	}
+
+	for i := range report.Rows {
+		report.Rows[i].Valid = len(report.Rows[i].Errors) == 0
+		if report.Rows[i].Valid {
+			report.ValidRows++
+		} else {
+			report.InvalidRows++
+		}
+	}
+	report.TotalRows = len(report.Rows)
+
+	return report, nil
+}
+
+// checkExisting flags rows that clash with existing students. In insert mode any
+// existing identifier is a conflict; otherwise rows are matched on student_id
+// and only identifiers owned by a different student conflict.
+func (s *ImportService) checkExisting(ctx context.Context, report *ValidationReport, students []models.Student, mode string) error {
+	// Student ID of the owner of each existing identifier
+	existing := map[string]map[string]string{
+		FieldStudentID:  {},
+		FieldEmail:      {},
+		FieldLeetcodeID: {},
+	}
+
+	for start := 0; start < len(students); start += identifierLookupChunk {
+		end := start + identifierLookupChunk
+		if end > len(students) {
+			end = len(students)
+		}
+
+		var studentIDs, emails, leetcodeIDs []string
+		for _, student := range students[start:end] {
+			studentIDs = append(studentIDs, student.StudentID)
+			emails = append(emails, student.Email)
+			leetcodeIDs = append(leetcodeIDs, student.LeetcodeID)
+		}
+
+		found, err := s.students.repo.FindByIdentifiers(ctx, studentIDs, emails, leetcodeIDs)
+		if err != nil {
+			return fmt.Errorf("failed to check existing students: %w", err)
+		}
+		for i := range found {
+			for _, id := range identifiers(&found[i]) {
+				existing[id.field][id.value] = found[i].StudentID
+			}
+		}
+	}
+
+	for i := range students {
+		row := &report.Rows[i]
+		if mode == ImportModeUpdateOnly && students[i].StudentID != "" {
+			if _, ok := existing[FieldStudentID][students[i].StudentID]; !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("student_id %q not found", students[i].StudentID))
+			}
+		}
+
+		for _, id := range identifiers(&students[i]) {
+			owner, ok := existing[id.field][id.value]
+			if id.value == "" || !ok {
+				continue
+			}
+			switch {
+			case mode == ImportModeInsert:
+				row.Errors = append(row.Errors, fmt.Sprintf("%s %q already exists", id.field, id.value))
+			case owner != students[i].StudentID:
+				row.Errors = append(row.Errors, fmt.Sprintf("%s %q already belongs to student %s", id.field, id.value, owner))
+			}
+		}
+	}
+	return nil
+}
+
+// validateImportedStudent checks the values of a row that parsed successfully
+func validateImportedStudent(student *models.Student) error {
+	var problems []string
+	if !emailPattern.MatchString(student.Email) {
+		problems = append(problems, fmt.Sprintf("invalid email %q", student.Email))
+	}
+
+	year := time.Now().Year()
+	if student.PassingYear < year-passingYearsBack || student.PassingYear > year+passingYearsAhead {
+		problems = append(problems, fmt.Sprintf("passing_year %d outside %d-%d",
+			student.PassingYear, year-passingYearsBack, year+passingYearsAhead))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// identifier is one of the unique fields of a student
+type identifier struct {
+	field string
+	value string
+}
+
+// identifiers returns the unique fields of a student in a fixed order
+func identifiers(student *models.Student) []identifier {
+	return []identifier{
+		{FieldStudentID, student.StudentID},
+		{FieldEmail, student.Email},
+		{FieldLeetcodeID, student.LeetcodeID},
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Leaderboard periods
+const (
+	LeaderboardPeriodWeek  = "week"
+	LeaderboardPeriodMonth = "month"
+	LeaderboardPeriodYear  = "year"
+	LeaderboardPeriodAll   = "all"
+)
+
+// maxLeaderboardPageSize caps how many entries one page may hold
+const maxLeaderboardPageSize = 100
+
+// ParseLeaderboardMetric validates a leaderboard metric, defaulting to rating
+func ParseLeaderboardMetric(metric string) (string, error) {
+	switch metric {
+	case "":
+		return models.LeaderboardMetricRating, nil
+	case models.LeaderboardMetricRating, models.LeaderboardMetricProblemsSolved,
+		models.LeaderboardMetricRatingGain, models.LeaderboardMetricContestRating,
+		models.LeaderboardMetricStreak, models.LeaderboardMetricLongestStreak,
+		models.LeaderboardMetricConsistency:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("invalid leaderboard metric %q: must be one of %s, %s, %s, %s, %s, %s, %s", metric,
+			models.LeaderboardMetricRating, models.LeaderboardMetricProblemsSolved,
+			models.LeaderboardMetricRatingGain, models.LeaderboardMetricContestRating,
+			models.LeaderboardMetricStreak, models.LeaderboardMetricLongestStreak,
+			models.LeaderboardMetricConsistency)
+	}
+}
+
+// ParseLeaderboardPeriod validates a leaderboard period, defaulting to week
+func ParseLeaderboardPeriod(period string) (string, error) {
+	switch period {
+	case "":
+		return LeaderboardPeriodWeek, nil
+	case LeaderboardPeriodWeek, LeaderboardPeriodMonth, LeaderboardPeriodYear, LeaderboardPeriodAll:
+		return period, nil
+	default:
+		return "", fmt.Errorf("invalid leaderboard period %q: must be one of %s, %s, %s, %s", period,
+			LeaderboardPeriodWeek, LeaderboardPeriodMonth, LeaderboardPeriodYear, LeaderboardPeriodAll)
+	}
+}
+
+// LeaderboardRequest selects a leaderboard page
+type LeaderboardRequest struct {
+	Metric     string
+	Period     string
+	Department string
+	Batch      string
+	Page       int
+	PageSize   int
+}
+
+// Leaderboard is one page of ranked students
+type Leaderboard struct {
+	Metric   string                    `json:"metric"`
+	Period   string                    `json:"period"`
+	Since    *time.Time                `json:"since,omitempty"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+	Total    int64                     `json:"total"`
+	Entries  []models.LeaderboardEntry `json:"entries"`
+}
+
+// LeaderboardService ranks students from their rating and contest snapshots
+type LeaderboardService struct {
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewLeaderboardService(students *StudentService, logger *zap.Logger) *LeaderboardService {
+	return &LeaderboardService{
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// GetLeaderboard returns a page of students ranked by the requested metric,
+// with each score's change over the period
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, req LeaderboardRequest) (*Leaderboard, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > maxLeaderboardPageSize {
+		req.PageSize = maxLeaderboardPageSize
+	}
+
+	now := s.calendar.Now()
+	start, prevStart := s.periodBounds(req.Period, now)
+
+	entries, total, err := s.repo.GetLeaderboard(ctx, repository.LeaderboardQuery{
+		Metric:     req.Metric,
+		Start:      start,
+		End:        now,
+		PrevStart:  prevStart,
+		Department: req.Department,
+		Batch:      req.Batch,
+		Offset:     (req.Page - 1) * req.PageSize,
+		Limit:      req.PageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank students: %w", err)
+	}
+
+	leaderboard := &Leaderboard{
+		Metric:   req.Metric,
+		Period:   req.Period,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Total:    total,
+		Entries:  entries,
+	}
+	if !start.IsZero() {
+		leaderboard.Since = &start
+	}
+	return leaderboard, nil
+}
+
+// GetTrendingStudents returns the students whose rating rose most over the
+// last days
+func (s *LeaderboardService) GetTrendingStudents(ctx context.Context, days, limit int) ([]models.LeaderboardEntry, error) {
+	if days < 1 {
+		days = 7
+	}
+	if limit < 1 || limit > maxLeaderboardPageSize {
+		limit = 10
+	}
+
+	now := time.Now()
+	start := now.AddDate(0, 0, -days)
+	entries, _, err := s.repo.GetLeaderboard(ctx, repository.LeaderboardQuery{
+		Metric:    models.LeaderboardMetricRatingGain,
+		Start:     start,
+		End:       now,
+		PrevStart: start.AddDate(0, 0, -days),
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank trending students: %w", err)
+	}
+	return entries, nil
+}
+
+// periodBounds returns the start of the period ending now and the start of
+// the period before it. The week period is the current calendar week; the
+// all-time period has zero bounds.
+func (s *LeaderboardService) periodBounds(period string, now time.Time) (time.Time, time.Time) {
+	switch period {
+	case LeaderboardPeriodWeek:
+		start := s.calendar.WeekStart(now)
+		return start, s.calendar.WeekStart(start.AddDate(0, 0, -1))
+	case LeaderboardPeriodMonth:
+		return now.AddDate(0, -1, 0), now.AddDate(0, -2, 0)
+	case LeaderboardPeriodYear:
+		return now.AddDate(-1, 0, 0), now.AddDate(-2, 0, 0)
+	default:
+		return time.Time{}, time.Time{}
+	}
+}
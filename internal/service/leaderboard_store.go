@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/cache"
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	leaderboardKeyPrefix = "leaderboard:rating:"
+	// weeklyLeaderboardTTL keeps a few past weeks of gains around for lookups
+	weeklyLeaderboardTTL = 8 * 7 * 24 * time.Hour
+	// rebuildPageSize is the number of weekly gains read per query on rebuild
+	rebuildPageSize = 1000
+)
+
+// LeaderboardScope picks one of the Redis leaderboards. Department and batch
+// boards rank by current rating; a week board ranks by rating gained during
+// the week starting on Week. Leaving everything empty selects the global board.
+type LeaderboardScope struct {
+	Department string
+	Batch      string
+	Week       time.Time
+}
+
+// Key is the Redis key of the scope's sorted set
+func (s LeaderboardScope) Key() string {
+	switch {
+	case !s.Week.IsZero():
+		return leaderboardKeyPrefix + "week:" + s.Week.Format("2006-01-02")
+	case s.Department != "":
+		return leaderboardKeyPrefix + "department:" + s.Department
+	case s.Batch != "":
+		return leaderboardKeyPrefix + "batch:" + s.Batch
+	default:
+		return leaderboardKeyPrefix + "global"
+	}
+}
+
+// RankedStudent is a student's position on a Redis leaderboard
+type RankedStudent struct {
+	Rank       int64   `json:"rank"`
+	ID         uint    `json:"id"`
+	StudentID  string  `json:"student_id,omitempty"`
+	Name       string  `json:"name,omitempty"`
+	Batch      string  `json:"batch,omitempty"`
+	Department string  `json:"department,omitempty"`
+	Score      float64 `json:"score"`
+}
+
+// LeaderboardStore keeps rating leaderboards as Redis sorted sets so rank
+// lookups and top-N reads are O(log n) instead of a SQL ranking per request.
+// Postgres stays the source of truth; Rebuild recreates every set from it.
+type LeaderboardStore struct {
+	cache    *cache.RedisCache
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewLeaderboardStore(redisCache *cache.RedisCache, students *StudentService, logger *zap.Logger) *LeaderboardStore {
+	return &LeaderboardStore{
+		cache:    redisCache,
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// RecordRating places a newly written rating on the global, department and
+// batch boards and updates the student's gain on the board of its week
+func (s *LeaderboardStore) RecordRating(ctx context.Context, rating *models.Rating) error {
+	student, err := s.repo.GetByID(ctx, rating.StudentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	member := studentMember(student.ID)
+	score := float64(rating.Rating)
+	for _, scope := range ratingScopes(student.Department, student.Batch) {
+		if err := s.cache.ZAdd(ctx, scope.Key(), member, score); err != nil {
+			return fmt.Errorf("failed to update %s: %w", scope.Key(), err)
+		}
+	}
+
+	week := s.calendar.WeekStart(rating.RecordedAt)
+	baseline, err := s.repo.GetBaselineRating(ctx, student.ID, week, rating.RecordedAt)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get baseline rating: %w", err)
+	}
+	gain := 0.0
+	if baseline != nil {
+		gain = float64(rating.Rating - baseline.Rating)
+	}
+
+	key := LeaderboardScope{Week: week}.Key()
+	if err := s.cache.ZAdd(ctx, key, member, gain); err != nil {
+		return fmt.Errorf("failed to update %s: %w", key, err)
+	}
+	if _, err := s.cache.Expire(ctx, key, weeklyLeaderboardTTL); err != nil {
+		return fmt.Errorf("failed to set expiry on %s: %w", key, err)
+	}
+
+	return s.publish(ctx, LeaderboardEvent{
+		Type:       LeaderboardEventRating,
+		StudentID:  student.ID,
+		Department: student.Department,
+		Batch:      student.Batch,
+		Week:       week,
+		At:         rating.RecordedAt,
+	})
+}
+
+// MoveStudent moves a student whose department or batch changed to the boards
+// of their new scope, and takes a deleted student, after being nil, off every
+// board including the weekly ones
+func (s *LeaderboardStore) MoveStudent(ctx context.Context, studentID uint, before, after *models.StudentSnapshot) error {
+	member := studentMember(studentID)
+
+	current := make(map[string]bool)
+	if after != nil {
+		for _, scope := range ratingScopes(after.Department, after.Batch) {
+			current[scope.Key()] = true
+		}
+	}
+	var stale []string
+	if before != nil {
+		for _, scope := range ratingScopes(before.Department, before.Batch) {
+			if !current[scope.Key()] {
+				stale = append(stale, scope.Key())
+			}
+		}
+	}
+	if after == nil {
+		weeks, err := s.cache.Scan(ctx, leaderboardKeyPrefix+"week:*")
+		if err != nil {
+			return fmt.Errorf("failed to list weekly boards: %w", err)
+		}
+		stale = append(stale, weeks...)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	// Read the score before removing the student from the global board
+	score, err := s.cache.ZScore(ctx, LeaderboardScope{}.Key(), member)
+	rated := err == nil
+	if err != nil && !cache.IsNil(err) {
+		return fmt.Errorf("failed to read %s: %w", LeaderboardScope{}.Key(), err)
+	}
+
+	for _, key := range stale {
+		if err := s.cache.ZRem(ctx, key, member); err != nil {
+			return fmt.Errorf("failed to update %s: %w", key, err)
+		}
+	}
+	if after == nil || !rated {
+		return nil
+	}
+	for key := range current {
+		if err := s.cache.ZAdd(ctx, key, member, score); err != nil {
+			return fmt.Errorf("failed to update %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// RecordContestResults announces that a student's contest history changed so
+// live leaderboards can refresh
+func (s *LeaderboardStore) RecordContestResults(ctx context.Context, studentID uint) error {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	now := s.calendar.Now()
+	return s.publish(ctx, LeaderboardEvent{
+		Type:       LeaderboardEventContests,
+		StudentID:  student.ID,
+		Department: student.Department,
+		Batch:      student.Batch,
+		Week:       s.calendar.WeekStart(now),
+		At:         now,
+	})
+}
+
+func (s *LeaderboardStore) publish(ctx context.Context, event LeaderboardEvent) error {
+	if err := s.cache.Publish(ctx, leaderboardEventsChannel, event); err != nil {
+		return fmt.Errorf("failed to publish leaderboard event: %w", err)
+	}
+	return nil
+}
+
+// Top returns count students from offset on the board, best first
+func (s *LeaderboardStore) Top(ctx context.Context, scope LeaderboardScope, offset, count int) ([]RankedStudent, int64, error) {
+	key := scope.Key()
+	members, err := s.cache.ZRevRangeWithScores(ctx, key, int64(offset), int64(offset+count-1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	total, err := s.cache.ZCard(ctx, key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count %s: %w", key, err)
+	}
+
+	ranked := make([]RankedStudent, 0, len(members))
+	ids := make([]uint, 0, len(members))
+	for i, m := range members {
+		id, err := strconv.ParseUint(m.Member, 10, 64)
+		if err != nil {
+			continue
+		}
+		ranked = append(ranked, RankedStudent{Rank: int64(offset + i + 1), ID: uint(id), Score: m.Score})
+		ids = append(ids, uint(id))
+	}
+
+	students, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load students: %w", err)
+	}
+	byID := make(map[uint]*models.Student, len(students))
+	for i := range students {
+		byID[students[i].ID] = &students[i]
+	}
+	for i := range ranked {
+		if student, ok := byID[ranked[i].ID]; ok {
+			ranked[i].StudentID = student.StudentID
+			ranked[i].Name = student.Name
+			ranked[i].Batch = student.Batch
+			ranked[i].Department = student.Department
+		}
+	}
+	return ranked, total, nil
+}
+
+// Rank returns a student's 1-based position and score on the board, or
+// ErrNotFound when they are not on it
+func (s *LeaderboardStore) Rank(ctx context.Context, scope LeaderboardScope, studentID uint) (*RankedStudent, error) {
+	key := scope.Key()
+	member := studentMember(studentID)
+
+	position, err := s.cache.ZRevRank(ctx, key, member)
+	if err != nil {
+		if cache.IsNil(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	score, err := s.cache.ZScore(ctx, key, member)
+	if err != nil {
+		if cache.IsNil(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return &RankedStudent{Rank: position + 1, ID: studentID, Score: score}, nil
+}
+
+// Rebuild recreates the global, department and batch boards and the boards of
+// the last weeks from Postgres. Each set is built under a temporary key and
+// swapped in, so readers never see a partial board.
+func (s *LeaderboardStore) Rebuild(ctx context.Context, weeks int) error {
+	start := time.Now()
+
+	latest, err := s.repo.ListLatestRatings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load latest ratings: %w", err)
+	}
+
+	boards := make(map[string][]cache.ScoredMember)
+	weekly := make(map[string]bool)
+	for _, score := range latest {
+		member := cache.ScoredMember{Member: studentMember(score.ID), Score: score.Score}
+		for _, scope := range ratingScopes(score.Department, score.Batch) {
+			boards[scope.Key()] = append(boards[scope.Key()], member)
+		}
+	}
+
+	now := s.calendar.Now()
+	week := s.calendar.WeekStart(now)
+	for i := 0; i < weeks; i++ {
+		gains, err := s.weeklyGains(ctx, week, now)
+		if err != nil {
+			return err
+		}
+		key := LeaderboardScope{Week: week}.Key()
+		boards[key] = gains
+		weekly[key] = true
+		now = week
+		week = s.calendar.WeekStart(week.AddDate(0, 0, -1))
+	}
+
+	// Boards of departments or batches that no longer have rated students
+	// would otherwise keep stale entries
+	existing, err := s.cache.Scan(ctx, leaderboardKeyPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to list leaderboard keys: %w", err)
+	}
+	for _, key := range existing {
+		if _, ok := boards[key]; !ok {
+			if err := s.cache.Del(ctx, key); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", key, err)
+			}
+		}
+	}
+
+	for key, members := range boards {
+		var ttl time.Duration
+		if weekly[key] {
+			ttl = weeklyLeaderboardTTL
+		}
+		if err := s.replaceBoard(ctx, key, members, ttl); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("Leaderboards rebuilt",
+		zap.String("service", "LeaderboardStore"),
+		zap.Int("boards", len(boards)),
+		zap.Int("students", len(latest)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+// weeklyGains returns every student's rating gain in the week starting at week
+func (s *LeaderboardStore) weeklyGains(ctx context.Context, week, end time.Time) ([]cache.ScoredMember, error) {
+	var members []cache.ScoredMember
+	for offset := 0; ; offset += rebuildPageSize {
+		entries, _, err := s.repo.GetLeaderboard(ctx, repository.LeaderboardQuery{
+			Metric: models.LeaderboardMetricRatingGain,
+			Start:  week,
+			End:    end,
+			Offset: offset,
+			Limit:  rebuildPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gains for week of %s: %w", week.Format("2006-01-02"), err)
+		}
+		for _, entry := range entries {
+			members = append(members, cache.ScoredMember{Member: studentMember(entry.ID), Score: entry.Score})
+		}
+		if len(entries) < rebuildPageSize {
+			return members, nil
+		}
+	}
+}
+
+// replaceBoard swaps in a new set for key, expiring it after ttl when ttl is set
+func (s *LeaderboardStore) replaceBoard(ctx context.Context, key string, members []cache.ScoredMember, ttl time.Duration) error {
+	if len(members) == 0 {
+		return s.cache.Del(ctx, key)
+	}
+
+	tmp := key + ":rebuild"
+	if err := s.cache.Del(ctx, tmp); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", tmp, err)
+	}
+	if err := s.cache.ZAddMulti(ctx, tmp, members); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := s.cache.Rename(ctx, tmp, key); err != nil {
+		return fmt.Errorf("failed to swap in %s: %w", key, err)
+	}
+	if ttl > 0 {
+		if _, err := s.cache.Expire(ctx, key, ttl); err != nil {
+			return fmt.Errorf("failed to set expiry on %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// TrackLeaderboards keeps the store up to date with every rating and contest
+// sync the student service performs and every student an import or rollback
+// moves or deletes
+func TrackLeaderboards(students *StudentService, store *LeaderboardStore, logger *zap.Logger) {
+	students.OnRatingRecorded(func(ctx context.Context, rating *models.Rating) {
+		if err := store.RecordRating(ctx, rating); err != nil {
+			logger.Error("Failed to update leaderboards",
+				zap.Uint("student_id", rating.StudentID),
+				zap.Error(err),
+			)
+		}
+	})
+	students.OnContestsUpdated(func(ctx context.Context, studentID uint) {
+		if err := store.RecordContestResults(ctx, studentID); err != nil {
+			logger.Error("Failed to announce contest results",
+				zap.Uint("student_id", studentID),
+				zap.Error(err),
+			)
+		}
+	})
+	students.OnStudentChanged(func(ctx context.Context, studentID uint, before, after *models.StudentSnapshot) {
+		if err := store.MoveStudent(ctx, studentID, before, after); err != nil {
+			logger.Error("Failed to move student between leaderboards",
+				zap.Uint("student_id", studentID),
+				zap.Error(err),
+			)
+		}
+	})
+}
+
+// ratingScopes are the rating boards a student appears on
+func ratingScopes(department, batch string) []LeaderboardScope {
+	scopes := []LeaderboardScope{{}}
+	if department != "" {
+		scopes = append(scopes, LeaderboardScope{Department: department})
+	}
+	if batch != "" {
+		scopes = append(scopes, LeaderboardScope{Batch: batch})
+	}
+	return scopes
+}
+
+func studentMember(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
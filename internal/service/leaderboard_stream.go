@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/cache"
+	"go.uber.org/zap"
+)
+
+// leaderboardEventsChannel is the Redis pub/sub channel leaderboard changes
+// are published on. Syncs run in the worker, so events cross processes.
+const leaderboardEventsChannel = "leaderboard:events"
+
+// Leaderboard event types
+const (
+	LeaderboardEventRating   = "rating"
+	LeaderboardEventContests = "contests"
+)
+
+// LeaderboardEvent announces that a student's standing may have changed
+type LeaderboardEvent struct {
+	Type       string    `json:"type"`
+	StudentID  uint      `json:"student_id"`
+	Department string    `json:"department,omitempty"`
+	Batch      string    `json:"batch,omitempty"`
+	Week       time.Time `json:"week"`
+	At         time.Time `json:"at"`
+}
+
+// Affects reports whether the event can change the board of scope. Week
+// boards only move with ratings; contest results reach them through the
+// next rating sync.
+func (e LeaderboardEvent) Affects(scope LeaderboardScope) bool {
+	switch {
+	case !scope.Week.IsZero():
+		return e.Type == LeaderboardEventRating && e.Week.Equal(scope.Week)
+	case scope.Department != "":
+		return e.Department == scope.Department
+	case scope.Batch != "":
+		return e.Batch == scope.Batch
+	default:
+		return true
+	}
+}
+
+// LeaderboardStream holds one Redis subscription for the process and fans its
+// events out to local subscribers such as SSE connections
+type LeaderboardStream struct {
+	cache  *cache.RedisCache
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]struct{}
+	stopped     bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type streamSubscriber struct {
+	scope  LeaderboardScope
+	events chan LeaderboardEvent
+}
+
+func NewLeaderboardStream(redisCache *cache.RedisCache, logger *zap.Logger) *LeaderboardStream {
+	return &LeaderboardStream{
+		cache:       redisCache,
+		logger:      logger,
+		subscribers: make(map[*streamSubscriber]struct{}),
+	}
+}
+
+func (s *LeaderboardStream) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.logger.Info("Leaderboard stream started",
+		zap.String("channel", leaderboardEventsChannel))
+
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop ends the Redis subscription and closes every subscriber's channel
+func (s *LeaderboardStream) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.stopped = true
+	for sub := range s.subscribers {
+		close(sub.events)
+		delete(s.subscribers, sub)
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("Leaderboard stream stopped")
+}
+
+// Subscribe returns a channel receiving the events that affect scope, and a
+// function to unsubscribe. Events arriving while the subscriber is still busy
+// with an earlier one are dropped, since each only signals that the board
+// should be read again. The channel is closed when the stream stops.
+func (s *LeaderboardStream) Subscribe(scope LeaderboardScope) (<-chan LeaderboardEvent, func()) {
+	sub := &streamSubscriber{
+		scope:  scope,
+		events: make(chan LeaderboardEvent, 1),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	s.subscribers[sub] = struct{}{}
+
+	return sub.events, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[sub]; ok {
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (s *LeaderboardStream) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	pubsub := s.cache.Subscribe(ctx, leaderboardEventsChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event LeaderboardEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				s.logger.Warn("Dropping malformed leaderboard event", zap.Error(err))
+				continue
+			}
+			s.dispatch(event)
+		}
+	}
+}
+
+func (s *LeaderboardStream) dispatch(event LeaderboardEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if !event.Affects(sub.scope) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
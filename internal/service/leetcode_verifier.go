@@ -0,0 +1,136 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/leetcode"
+	"golang.org/x/time/rate"
+)
+
+// Verification policies for LeetCode IDs during import
+const (
+	VerifyOff  = "off"  // no lookups
+	VerifyFail = "fail" // rows with unknown handles fail
+	VerifyFlag = "flag" // rows with unknown handles are imported with a warning
+)
+
+// ParseVerifyPolicy validates a verification policy, defaulting to off
+func ParseVerifyPolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return VerifyOff, nil
+	case VerifyOff, VerifyFail, VerifyFlag:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid verify policy %q: must be one of %s, %s, %s",
+			policy, VerifyOff, VerifyFail, VerifyFlag)
+	}
+}
+
+// VerifierConfig tunes the LeetCode lookups made during import
+type VerifierConfig struct {
+	RequestsPerMinute int
+	CacheTTL          time.Duration
+	CacheSize         int // most handles cached; the least recently used go first
+}
+
+type verification struct {
+	username  string
+	profile   *leetcode.UserProfile
+	err       error
+	checkedAt time.Time
+}
+
+// LeetCodeVerifier checks that LeetCode handles exist. Lookups are rate limited
+// and both hits and misses are cached, so re-uploading a roster is cheap. The
+// cache holds at most CacheSize handles, evicting the least recently used.
+type LeetCodeVerifier struct {
+	leetcode *leetcode.Service
+	limiter  *rate.Limiter
+	ttl      time.Duration
+	size     int
+
+	mu    sync.Mutex
+	cache map[string]*list.Element // values are verifications
+	order *list.List               // most recently used first
+}
+
+func NewLeetCodeVerifier(leetcodeService *leetcode.Service, config VerifierConfig) *LeetCodeVerifier {
+	limit := rate.Inf
+	if config.RequestsPerMinute > 0 {
+		limit = rate.Limit(float64(config.RequestsPerMinute) / 60)
+	}
+	return &LeetCodeVerifier{
+		leetcode: leetcodeService,
+		limiter:  rate.NewLimiter(limit, 1),
+		ttl:      config.CacheTTL,
+		size:     config.CacheSize,
+		cache:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Verify returns the profile of a LeetCode user, leetcode.ErrUserNotFound when
+// the handle does not exist, or another error when the lookup itself failed
+func (v *LeetCodeVerifier) Verify(ctx context.Context, username string) (*leetcode.UserProfile, error) {
+	if cached, ok := v.cached(username); ok {
+		return cached.profile, cached.err
+	}
+
+	if err := v.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	profile, err := v.leetcode.GetUserProfile(username)
+	if err != nil && !errors.Is(err, leetcode.ErrUserNotFound) {
+		// Transient failures are not cached
+		return nil, err
+	}
+
+	v.store(verification{username: username, profile: profile, err: err, checkedAt: time.Now()})
+	return profile, err
+}
+
+// cached returns an unexpired verification of a handle, dropping it once it
+// has expired
+func (v *LeetCodeVerifier) cached(username string) (verification, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	element, ok := v.cache[username]
+	if !ok {
+		return verification{}, false
+	}
+	entry := element.Value.(verification)
+	if time.Since(entry.checkedAt) >= v.ttl {
+		v.order.Remove(element)
+		delete(v.cache, username)
+		return verification{}, false
+	}
+	v.order.MoveToFront(element)
+	return entry, true
+}
+
+// store caches a verification, evicting the least recently used handles
+// beyond the cache size
+func (v *LeetCodeVerifier) store(entry verification) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if element, ok := v.cache[entry.username]; ok {
+		element.Value = entry
+		v.order.MoveToFront(element)
+	} else {
+		v.cache[entry.username] = v.order.PushFront(entry)
+	}
+	for v.size > 0 && v.order.Len() > v.size {
+		oldest := v.order.Back()
+		v.order.Remove(oldest)
+		delete(v.cache, oldest.Value.(verification).username)
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/database"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+//go:embed templates/weekly_report.html
+var reportTemplates embed.FS
+
+// topImproverCount is the number of students listed as top improvers
+const topImproverCount = 10
+
+// ReportFilter selects the students a report covers
+type ReportFilter struct {
+	Batch      string
+	Department string
+}
+
+// ReportStudent is a student line in a weekly report
+type ReportStudent struct {
+	StudentID        string
+	Name             string
+	Batch            string
+	Department       string
+	ProblemsSolved   int
+	ContestsAttended int
+	RatingStart      int
+	RatingEnd        int
+	RatingGain       int
+}
+
+// DifficultyMix is the split of problems solved by difficulty
+type DifficultyMix struct {
+	Easy        int     `json:"easy"`
+	Medium      int     `json:"medium"`
+	Hard        int     `json:"hard"`
+	EasyShare   float64 `json:"easy_share"`
+	MediumShare float64 `json:"medium_share"`
+	HardShare   float64 `json:"hard_share"`
+	Total       int     `json:"total"`
+}
+
+// computeShares sets the total and each difficulty's share of it
+func (m *DifficultyMix) computeShares() {
+	m.Total = m.Easy + m.Medium + m.Hard
+	if m.Total > 0 {
+		total := float64(m.Total)
+		m.EasyShare = float64(m.Easy) / total
+		m.MediumShare = float64(m.Medium) / total
+		m.HardShare = float64(m.Hard) / total
+	}
+}
+
+// WeeklyReportData is everything the weekly report template renders
+type WeeklyReportData struct {
+	Title               string
+	Batch               string
+	Department          string
+	WeekStart           time.Time
+	WeekEnd             time.Time
+	GeneratedAt         time.Time
+	TotalStudents       int
+	ActiveStudents      int
+	ContestParticipants int
+	Difficulty          DifficultyMix
+	TopImprovers        []ReportStudent
+	Inactive            []ReportStudent
+	Contests            []models.ContestParticipation
+}
+
+// ReportService renders weekly digests for a batch or department and keeps
+// them for later retrieval
+type ReportService struct {
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	reports  *database.WeeklyReportDB
+	template *template.Template
+	logger   *zap.Logger
+}
+
+func NewReportService(students *StudentService, logger *zap.Logger) *ReportService {
+	tmpl := template.Must(template.New("weekly_report.html").Funcs(template.FuncMap{
+		"date":    func(t time.Time) string { return t.In(students.calendar.Location()).Format("02 Jan 2006") },
+		"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+		"signed":  func(v int) string { return fmt.Sprintf("%+d", v) },
+	}).ParseFS(reportTemplates, "templates/weekly_report.html"))
+
+	return &ReportService{
+		repo:     students.repo,
+		calendar: students.calendar,
+		reports:  database.NewWeeklyReportDB(students.repo.DB),
+		template: tmpl,
+		logger:   logger,
+	}
+}
+
+// GenerateWeekly renders the report for the week containing week. When store
+// is set the rendered report is saved and returned with its ID.
+func (s *ReportService) GenerateWeekly(ctx context.Context, filter ReportFilter, week time.Time, store bool) (*models.WeeklyReport, error) {
+	logger := s.logger.With(
+		zap.String("service", "ReportService"),
+		zap.String("batch", filter.Batch),
+		zap.String("department", filter.Department),
+	)
+
+	weekStart := s.calendar.WeekStart(week)
+	weekEnd := s.calendar.WeekEnd(week)
+
+	data, err := s.buildWeekly(ctx, filter, weekStart, weekEnd)
+	if err != nil {
+		logger.Error("Failed to collect report data", zap.Error(err))
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := s.template.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render weekly report: %w", err)
+	}
+
+	report := &models.WeeklyReport{
+		Batch:      filter.Batch,
+		Department: filter.Department,
+		WeekStart:  weekStart,
+		WeekEnd:    weekEnd,
+		HTML:       html.String(),
+		CreatedAt:  data.GeneratedAt,
+	}
+	if store {
+		if err := s.reports.Create(ctx, report); err != nil {
+			return nil, err
+		}
+		logger.Info("Weekly report stored",
+			zap.Uint("report_id", report.ID),
+			zap.Time("week_start", weekStart),
+		)
+	}
+	return report, nil
+}
+
+// GetReport returns a stored report
+func (s *ReportService) GetReport(ctx context.Context, id uint) (*models.WeeklyReport, error) {
+	report, err := s.reports.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns a page of stored reports, newest week first
+func (s *ReportService) ListReports(ctx context.Context, page, pageSize int, filter ReportFilter) ([]models.WeeklyReport, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.reports.List(ctx, (page-1)*pageSize, pageSize, filter.Batch, filter.Department)
+}
+
+func (s *ReportService) buildWeekly(ctx context.Context, filter ReportFilter, weekStart, weekEnd time.Time) (*WeeklyReportData, error) {
+	rows, err := s.repo.ListWeeklyReportRows(ctx, filter.Department, filter.Batch, weekStart, weekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load weekly activity: %w", err)
+	}
+	contests, err := s.repo.ListContestParticipation(ctx, filter.Department, filter.Batch, weekStart, weekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load contest participation: %w", err)
+	}
+
+	data := &WeeklyReportData{
+		Title:         reportTitle(filter),
+		Batch:         filter.Batch,
+		Department:    filter.Department,
+		WeekStart:     weekStart,
+		WeekEnd:       weekEnd.AddDate(0, 0, -1),
+		GeneratedAt:   time.Now(),
+		TotalStudents: len(rows),
+		Contests:      contests,
+	}
+
+	var improvers []ReportStudent
+	for i := range rows {
+		row := &rows[i]
+		student := reportStudent(row)
+
+		data.Difficulty.Easy += row.EasySolved
+		data.Difficulty.Medium += row.MediumSolved
+		data.Difficulty.Hard += row.HardSolved
+		if row.ContestsAttended > 0 {
+			data.ContestParticipants++
+		}
+
+		if row.ProblemsSolved == 0 && row.ContestsAttended == 0 {
+			data.Inactive = append(data.Inactive, student)
+			continue
+		}
+		data.ActiveStudents++
+		if row.RatingStart != nil && row.RatingEnd != nil && student.RatingGain > 0 {
+			improvers = append(improvers, student)
+		}
+	}
+
+	sort.SliceStable(improvers, func(i, j int) bool {
+		if improvers[i].RatingGain != improvers[j].RatingGain {
+			return improvers[i].RatingGain > improvers[j].RatingGain
+		}
+		return improvers[i].ProblemsSolved > improvers[j].ProblemsSolved
+	})
+	if len(improvers) > topImproverCount {
+		improvers = improvers[:topImproverCount]
+	}
+	data.TopImprovers = improvers
+
+	data.Difficulty.computeShares()
+
+	return data, nil
+}
+
+func reportStudent(row *models.WeeklyReportRow) ReportStudent {
+	student := ReportStudent{
+		StudentID:        row.StudentID,
+		Name:             row.Name,
+		Batch:            row.Batch,
+		Department:       row.Department,
+		ProblemsSolved:   row.ProblemsSolved,
+		ContestsAttended: row.ContestsAttended,
+	}
+	if row.RatingStart != nil {
+		student.RatingStart = *row.RatingStart
+	}
+	if row.RatingEnd != nil {
+		student.RatingEnd = *row.RatingEnd
+	}
+	if row.RatingStart != nil && row.RatingEnd != nil {
+		student.RatingGain = *row.RatingEnd - *row.RatingStart
+	}
+	return student
+}
+
+func reportTitle(filter ReportFilter) string {
+	var scope []string
+	if filter.Department != "" {
+		scope = append(scope, filter.Department)
+	}
+	if filter.Batch != "" {
+		scope = append(scope, "Batch "+filter.Batch)
+	}
+	if len(scope) == 0 {
+		return "Weekly Report"
+	}
+	return "Weekly Report: " + strings.Join(scope, ", ")
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// RiskService flags students who have gone quiet so mentors can reach out.
+// A student is at risk when their stats have not been synced within the
+// window, or when in the window they neither solved a problem nor saw their
+// rating move.
+type RiskService struct {
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewRiskService(students *StudentService, logger *zap.Logger) *RiskService {
+	return &RiskService{
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// Detect re-evaluates every student over the window ending now, opening and
+// resolving flags to match, and returns how many students are at risk
+func (s *RiskService) Detect(ctx context.Context, window time.Duration) (int, error) {
+	start := time.Now()
+	now := s.calendar.Now()
+	since := now.Add(-window)
+
+	signals, err := s.repo.ListActivitySignals(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list activity signals: %w", err)
+	}
+
+	var flags []models.AtRiskFlag
+	atRisk := 0
+	for _, signal := range signals {
+		reasons := s.evaluate(signal, since, window)
+		if len(reasons) > 0 {
+			atRisk++
+		}
+		flags = append(flags, reasons...)
+	}
+
+	opened, resolved, err := s.repo.SyncRiskFlags(ctx, flags, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store at-risk flags: %w", err)
+	}
+
+	s.logger.Info("Inactivity detection completed",
+		zap.String("service", "RiskService"),
+		zap.Duration("window", window),
+		zap.Int("students", len(signals)),
+		zap.Int("at_risk", atRisk),
+		zap.Int("opened", opened),
+		zap.Int("resolved", resolved),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return atRisk, nil
+}
+
+// ListAtRisk returns the flagged students, optionally limited to a department
+// and batch
+func (s *RiskService) ListAtRisk(ctx context.Context, department, batch string) ([]models.AtRiskStudent, error) {
+	students, err := s.repo.ListAtRiskStudents(ctx, department, batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list at-risk students: %w", err)
+	}
+	return students, nil
+}
+
+// evaluate returns the flags a student's activity raises. Solves count when
+// any source shows one: LeetCode's last solve time, the solved count across
+// rating snapshots or the weekly stats.
+func (s *RiskService) evaluate(signal models.ActivitySignal, since time.Time, window time.Duration) []models.AtRiskFlag {
+	days := int(window.Hours() / 24)
+
+	if signal.LastSyncedAt == nil {
+		return []models.AtRiskFlag{{
+			StudentID: signal.StudentID,
+			Reason:    models.RiskReasonNoRecentSync,
+			Detail:    "stats have never been synced",
+		}}
+	}
+	if signal.LastSyncedAt.Before(since) {
+		return []models.AtRiskFlag{{
+			StudentID: signal.StudentID,
+			Reason:    models.RiskReasonNoRecentSync,
+			Detail:    fmt.Sprintf("stats last synced on %s", s.calendar.FormatDate(*signal.LastSyncedAt)),
+		}}
+	}
+
+	solved := signal.WeeklySolved > 0 ||
+		(signal.LastSolvedAt != nil && !signal.LastSolvedAt.Before(since)) ||
+		(signal.ProblemsDelta != nil && *signal.ProblemsDelta > 0)
+	ratingChanged := signal.RatingDelta != nil && *signal.RatingDelta != 0
+	if solved || ratingChanged {
+		return nil
+	}
+
+	solvesDetail := fmt.Sprintf("no problems solved in the last %d days", days)
+	if signal.LastSolvedAt != nil && !signal.LastSolvedAt.IsZero() {
+		solvesDetail += fmt.Sprintf(", last solve on %s", s.calendar.FormatDate(*signal.LastSolvedAt))
+	}
+	ratingDetail := fmt.Sprintf("rating unchanged in the last %d days", days)
+	if signal.CurrentRating != nil {
+		ratingDetail = fmt.Sprintf("rating unchanged at %d in the last %d days", *signal.CurrentRating, days)
+	}
+	return []models.AtRiskFlag{
+		{StudentID: signal.StudentID, Reason: models.RiskReasonNoNewSolves, Detail: solvesDetail},
+		{StudentID: signal.StudentID, Reason: models.RiskReasonNoRatingChange, Detail: ratingDetail},
+	}
+}
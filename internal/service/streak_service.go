@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// consistencyWeeks is how many calendar weeks, the current one included, the
+// weekly half of the consistency score looks back over
+const consistencyWeeks = 12
+
+// StreakService keeps daily progress and the streaks derived from it. A day
+// counts as active when the student solved a problem or made a submission on
+// it; submissions come from LeetCode's calendar, which dates activity
+// correctly even when stats are synced days apart.
+type StreakService struct {
+	students *StudentService
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewStreakService(students *StudentService, logger *zap.Logger) *StreakService {
+	return &StreakService{
+		students: students,
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// RecordRating updates the student's daily progress for the day of a new
+// snapshot, and for the following day when it has started since that day's
+// baseline may be the new snapshot, refreshes their submission calendar, then
+// recalculates their streak. A calendar that cannot be fetched is logged and
+// skipped so the snapshot-derived progress is still stored.
+func (s *StreakService) RecordRating(ctx context.Context, rating *models.Rating) error {
+	if err := s.syncSubmissions(ctx, rating.StudentID); err != nil {
+		s.logger.Warn("Failed to sync submission calendar",
+			zap.String("service", "StreakService"),
+			zap.Uint("student_id", rating.StudentID),
+			zap.Error(err),
+		)
+	}
+
+	day := s.calendar.StartOfDay(rating.RecordedAt)
+	days := []time.Time{day}
+	if next := day.AddDate(0, 0, 1); !next.After(s.calendar.Now()) {
+		days = append(days, next)
+	}
+
+	for _, day := range days {
+		date := s.calendar.FormatDate(day)
+		if err := s.repo.ComputeDailyProgress(ctx, rating.StudentID, date, day, day.AddDate(0, 0, 1)); err != nil {
+			return fmt.Errorf("failed to compute daily progress for %s: %w", date, err)
+		}
+	}
+
+	_, err := s.Recalculate(ctx, rating.StudentID)
+	return err
+}
+
+// syncSubmissions stores the student's daily submission counts from their
+// LeetCode submission calendar
+func (s *StreakService) syncSubmissions(ctx context.Context, studentID uint) error {
+	student, err := s.students.GetStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	submissions, err := s.students.leetcodeService.GetSubmissionCalendar(student.LeetcodeID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch submission calendar: %w", err)
+	}
+	if err := s.repo.UpsertDailySubmissions(ctx, studentID, submissions); err != nil {
+		return fmt.Errorf("failed to store submissions: %w", err)
+	}
+	return nil
+}
+
+// Recalculate recomputes and stores one student's streak
+func (s *StreakService) Recalculate(ctx context.Context, studentID uint) (*models.StudentStreak, error) {
+	dates, err := s.repo.ListActiveDates(ctx, []uint{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active days: %w", err)
+	}
+
+	streak, err := s.calculate(studentID, dates[studentID], s.calendar.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertStudentStreaks(ctx, []models.StudentStreak{*streak}); err != nil {
+		return nil, fmt.Errorf("failed to store streak: %w", err)
+	}
+	return streak, nil
+}
+
+// RecalculateAll recomputes every student's streak, so streaks lapse for
+// students who stopped solving, and returns how many were stored
+func (s *StreakService) RecalculateAll(ctx context.Context) (int, error) {
+	start := time.Now()
+	ids, err := s.repo.ListStudentIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list students: %w", err)
+	}
+	dates, err := s.repo.ListActiveDates(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active days: %w", err)
+	}
+
+	now := s.calendar.Now()
+	streaks := make([]models.StudentStreak, 0, len(ids))
+	for _, id := range ids {
+		streak, err := s.calculate(id, dates[id], now)
+		if err != nil {
+			return 0, err
+		}
+		streaks = append(streaks, *streak)
+	}
+	if err := s.repo.UpsertStudentStreaks(ctx, streaks); err != nil {
+		return 0, fmt.Errorf("failed to store streaks: %w", err)
+	}
+
+	s.logger.Info("Streaks recalculated",
+		zap.String("service", "StreakService"),
+		zap.Int("students", len(streaks)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return len(streaks), nil
+}
+
+// GetStreak returns a student's stored streak
+func (s *StreakService) GetStreak(ctx context.Context, studentID uint) (*models.StudentStreak, error) {
+	streak, err := s.repo.GetStudentStreak(ctx, studentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get streak: %w", err)
+	}
+	return streak, nil
+}
+
+// GetStudentStats returns a student's stats along with their streak
+func (s *StreakService) GetStudentStats(ctx context.Context, studentID uint) (*models.StudentStats, error) {
+	if _, err := s.students.GetStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetStudentStats(ctx, studentID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get student stats: %w", err)
+		}
+		// Not synced yet
+		stats = &models.StudentStats{StudentID: studentID}
+	}
+
+	streak, err := s.GetStreak(ctx, studentID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	stats.Streak = streak
+	return stats, nil
+}
+
+// calculate derives a streak from the ordered YYYY-MM-DD dates a student was
+// active on. The current streak still counts when today has no activity yet,
+// as long as it reached yesterday. The consistency score, from 0 to 100,
+// weighs active days over the last 30 days at 60% and active weeks over the
+// last 12 calendar weeks at 40%, so steady weekly practice scores well even
+// without daily solving.
+func (s *StreakService) calculate(studentID uint, dates []string, now time.Time) (*models.StudentStreak, error) {
+	streak := &models.StudentStreak{
+		StudentID: studentID,
+		UpdatedAt: now,
+	}
+
+	firstWeek := s.calendar.WeekStart(now).AddDate(0, 0, -7*(consistencyWeeks-1))
+	activeWeeks := make(map[time.Time]struct{})
+
+	run := 0
+	var previous time.Time
+	for i, value := range dates {
+		day, err := s.calendar.ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active date %q: %w", value, err)
+		}
+
+		if i > 0 && s.calendar.DaysBetween(previous, day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > streak.LongestStreak {
+			streak.LongestStreak = run
+		}
+
+		ago := s.calendar.DaysBetween(day, now)
+		if ago < 30 {
+			streak.ActiveDays30++
+		}
+		if ago < 90 {
+			streak.ActiveDays90++
+		}
+		if week := s.calendar.WeekStart(day); !week.Before(firstWeek) {
+			activeWeeks[week] = struct{}{}
+		}
+		previous = day
+	}
+
+	if len(dates) > 0 {
+		last := previous
+		streak.LastActiveDate = &last
+		if s.calendar.DaysBetween(last, now) <= 1 {
+			streak.CurrentStreak = run
+		}
+	}
+
+	score := 100 * (0.6*float64(streak.ActiveDays30)/30 + 0.4*float64(len(activeWeeks))/consistencyWeeks)
+	streak.ConsistencyScore = math.Round(score*10) / 10
+	return streak, nil
+}
+
+// TrackStreaks keeps daily progress and streaks current with every rating
+// snapshot the student service stores
+func TrackStreaks(students *StudentService, streaks *StreakService, logger *zap.Logger) {
+	students.OnRatingRecorded(func(ctx context.Context, rating *models.Rating) {
+		if err := streaks.RecordRating(ctx, rating); err != nil {
+			logger.Error("Failed to update streak",
+				zap.Uint("student_id", rating.StudentID),
+				zap.Error(err),
+			)
+		}
+	})
+}
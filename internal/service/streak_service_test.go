@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+)
+
+func TestStreakCalculate(t *testing.T) {
+	cal, err := calendar.New("UTC", "monday", "august", 4)
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+	s := &StreakService{calendar: cal}
+	// A Wednesday; its week starts on Monday 2026-03-09.
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dates    []string
+		wantErr  bool
+		current  int
+		longest  int
+		active30 int
+		active90 int
+		score    float64
+	}{
+		{
+			name: "no activity",
+		},
+		{
+			name:     "run ending today",
+			dates:    []string{"2026-03-09", "2026-03-10", "2026-03-11"},
+			current:  3,
+			longest:  3,
+			active30: 3,
+			active90: 3,
+			score:    9.3,
+		},
+		{
+			name:     "run ending yesterday",
+			dates:    []string{"2026-03-09", "2026-03-10"},
+			current:  2,
+			longest:  2,
+			active30: 2,
+			active90: 2,
+			score:    7.3,
+		},
+		{
+			name:     "broken run keeps the longest",
+			dates:    []string{"2026-02-01", "2026-02-02", "2026-02-03", "2026-02-04", "2026-03-08"},
+			current:  0,
+			longest:  4,
+			active30: 1,
+			active90: 5,
+			score:    12,
+		},
+		{
+			name:     "activity outside the windows",
+			dates:    []string{"2025-06-01", "2025-06-02"},
+			longest:  2,
+			active30: 0,
+			active90: 0,
+		},
+		{
+			name:    "invalid date",
+			dates:   []string{"2026-13-01"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.calculate(1, tt.dates, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("calculate() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("calculate() error = %v", err)
+			}
+			if got.CurrentStreak != tt.current || got.LongestStreak != tt.longest {
+				t.Errorf("calculate() streak = %d/%d, want %d/%d", got.CurrentStreak, got.LongestStreak, tt.current, tt.longest)
+			}
+			if got.ActiveDays30 != tt.active30 || got.ActiveDays90 != tt.active90 {
+				t.Errorf("calculate() active days = %d/%d, want %d/%d", got.ActiveDays30, got.ActiveDays90, tt.active30, tt.active90)
+			}
+			if got.ConsistencyScore != tt.score {
+				t.Errorf("calculate() score = %v, want %v", got.ConsistencyScore, tt.score)
+			}
+			if (got.LastActiveDate == nil) != (len(tt.dates) == 0) {
+				t.Errorf("calculate() last active date = %v with %d dates", got.LastActiveDate, len(tt.dates))
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ayush/ORBIT/internal/calendar"
 	"github.com/ayush/ORBIT/internal/database"
 	"github.com/ayush/ORBIT/internal/leetcode"
 	"github.com/ayush/ORBIT/internal/models"
@@ -19,18 +20,60 @@ var (
 
 type StudentService struct {
 	repo            *repository.StudentRepository
+	calendar        *calendar.Calendar
 	logger          *zap.Logger
 	leetcodeService *leetcode.Service
+	ratingHooks     []func(ctx context.Context, rating *models.Rating)
+	contestHooks    []func(ctx context.Context, studentID uint)
+	studentHooks    []func(ctx context.Context, studentID uint, before, after *models.StudentSnapshot)
 }
 
-func NewStudentService(repo *repository.StudentRepository, logger *zap.Logger) *StudentService {
+func NewStudentService(repo *repository.StudentRepository, cal *calendar.Calendar, logger *zap.Logger) *StudentService {
 	return &StudentService{
 		repo:            repo,
+		calendar:        cal,
 		logger:          logger,
 		leetcodeService: leetcode.NewService(),
 	}
 }
 
+// OnRatingRecorded adds a callback run after every rating snapshot is stored.
+// It must be called before the service is used.
+func (s *StudentService) OnRatingRecorded(fn func(ctx context.Context, rating *models.Rating)) {
+	s.ratingHooks = append(s.ratingHooks, fn)
+}
+
+func (s *StudentService) ratingRecorded(ctx context.Context, rating *models.Rating) {
+	for _, fn := range s.ratingHooks {
+		fn(ctx, rating)
+	}
+}
+
+// OnContestsUpdated adds a callback run after a student's contest history is
+// replaced. It must be called before the service is used.
+func (s *StudentService) OnContestsUpdated(fn func(ctx context.Context, studentID uint)) {
+	s.contestHooks = append(s.contestHooks, fn)
+}
+
+func (s *StudentService) contestsUpdated(ctx context.Context, studentID uint) {
+	for _, fn := range s.contestHooks {
+		fn(ctx, studentID)
+	}
+}
+
+// OnStudentChanged adds a callback run after an import or rollback commits a
+// change to a student's importable fields, with after nil when the student
+// was deleted. It must be called before the service is used.
+func (s *StudentService) OnStudentChanged(fn func(ctx context.Context, studentID uint, before, after *models.StudentSnapshot)) {
+	s.studentHooks = append(s.studentHooks, fn)
+}
+
+func (s *StudentService) studentChanged(ctx context.Context, studentID uint, before, after *models.StudentSnapshot) {
+	for _, fn := range s.studentHooks {
+		fn(ctx, studentID, before, after)
+	}
+}
+
 // ListStudents retrieves a paginated list of students
 func (s *StudentService) ListStudents(ctx context.Context, page, pageSize int, department, batch string) ([]*models.Student, error) {
 	offset := (page - 1) * pageSize
@@ -80,41 +123,49 @@ func (s *StudentService) GetLeetCodeStats(ctx context.Context, id interface{}) (
 		return nil, fmt.Errorf("failed to get contest ranking: %w", err)
 	}
 
-	// Calculate total submissions from individual counts
-	totalSolved := 0
-	for _, submission := range profile.Data.MatchedUser.SubmitStats.AcSubmissionNum {
-		totalSolved += submission.Count
-	}
+	stats := statsFromProfile(student.ID, profile)
+	stats.ContestsParticipated = contestInfo.Data.UserContestRanking.AttendedContestsCount
+	stats.ContestRating = float64(contestInfo.Data.UserContestRanking.Rating)
+	stats.ContestGlobalRanking = contestInfo.Data.UserContestRanking.GlobalRanking
+	// A fetch cannot tell when the last solve happened; RefreshLeetCodeStats
+	// keeps the previous time unless the solved count went up
+	stats.LastSolvedAt = stats.CreatedAt
 
-	// Map the data to our stats model
+	return stats, nil
+}
+
+// statsFromProfile maps a LeetCode profile onto a stats snapshot. The solved
+// total is LeetCode's "All" bucket, which already sums the difficulties.
+// LastSolvedAt is left zero because a profile carries no solve times.
+func statsFromProfile(studentID uint, profile *leetcode.UserProfile) *models.LeetCodeStats {
+	now := time.Now()
 	stats := &models.LeetCodeStats{
-		StudentID:            student.ID,
-		Rating:               profile.Data.MatchedUser.Profile.Ranking,
-		GlobalRanking:        profile.Data.MatchedUser.Profile.Ranking,
-		TotalSolved:          totalSolved,
-		ContestsParticipated: len(contestInfo.Data.UserContestRankingHistory),
-		ContestRating:        float64(contestInfo.Data.UserContestRanking.Rating),
-		ContestGlobalRanking: contestInfo.Data.UserContestRanking.GlobalRanking,
-		LastSolvedAt:         time.Now(),
-		InitialRating:        profile.Data.MatchedUser.Profile.Ranking,
-		CreatedAt:            time.Now(),
-		UpdatedAt:            time.Now(),
-	}
-
-	// Calculate solved problems by difficulty
+		StudentID:     studentID,
+		Rating:        profile.Data.MatchedUser.Profile.Ranking,
+		GlobalRanking: profile.Data.MatchedUser.Profile.Ranking,
+		InitialRating: profile.Data.MatchedUser.Profile.Ranking,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	total := -1
 	for _, submission := range profile.Data.MatchedUser.SubmitStats.AcSubmissionNum {
-		count := submission.Count
 		switch submission.Difficulty {
+		case "All":
+			total = submission.Count
 		case "Easy":
-			stats.EasySolved = count
+			stats.EasySolved = submission.Count
 		case "Medium":
-			stats.MediumSolved = count
+			stats.MediumSolved = submission.Count
 		case "Hard":
-			stats.HardSolved = count
+			stats.HardSolved = submission.Count
 		}
 	}
-
-	return stats, nil
+	if total < 0 {
+		total = stats.EasySolved + stats.MediumSolved + stats.HardSolved
+	}
+	stats.TotalSolved = total
+	return stats
 }
 
 // GetDailyProgress retrieves a student's daily progress
@@ -127,12 +178,12 @@ func (s *StudentService) GetDailyProgress(ctx context.Context, id uint, startDat
 		return nil, err
 	}
 
-	start, err := time.Parse("2006-01-02", startDate)
+	start, err := s.calendar.ParseDate(startDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start date: %w", err)
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := s.calendar.ParseDate(endDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid end date: %w", err)
 	}
@@ -150,11 +201,8 @@ func (s *StudentService) GetWeeklyStats(ctx context.Context, id uint) (*models.W
 		return nil, err
 	}
 
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekEnd := weekStart.AddDate(0, 0, 7)
-
-	return s.repo.GetWeeklyStats(ctx, student.ID, weekStart, weekEnd)
+	now := s.calendar.Now()
+	return s.repo.GetWeeklyStats(ctx, student.ID, s.calendar.WeekStart(now), s.calendar.WeekEnd(now))
 }
 
 // GetContestHistory retrieves a student's contest history
@@ -190,59 +238,40 @@ func (s *StudentService) UpdateContestHistory(ctx context.Context, id uint) ([]m
 		return nil, fmt.Errorf("failed to delete existing contest history: %w", err)
 	}
 
-	// Create new contest history entries
+	// Create new contest history entries. LeetCode lists every contest held
+	// since the user registered; only the ones they entered are kept.
 	var histories []models.ContestHistory
-	var historyPtrs []*models.ContestHistory
 	now := time.Now()
 
 	for _, contest := range contestInfo.Data.UserContestRankingHistory {
-		history := models.ContestHistory{
+		if !contest.Attended {
+			continue
+		}
+		histories = append(histories, models.ContestHistory{
 			StudentID:         student.ID,
 			ContestTitle:      contest.Contest.Title,
-			ContestDate:       now,
+			ContestDate:       time.Unix(contest.Contest.StartTime, 0).UTC(),
 			Rating:            contest.Rating,
 			Ranking:           contest.Ranking,
 			ProblemsSolved:    contest.ProblemsSolved,
 			FinishTimeSeconds: int64(contest.FinishTimeInSeconds),
 			CreatedAt:         now,
-		}
-		histories = append(histories, history)
-		historyPtrs = append(historyPtrs, &histories[len(histories)-1])
+		})
+	}
+	historyPtrs := make([]*models.ContestHistory, len(histories))
+	for i := range histories {
+		historyPtrs[i] = &histories[i]
 	}
 
 	// Add new contest histories using pointer slice
 	if err := s.repo.AddContestHistories(ctx, student.ID, historyPtrs); err != nil {
 		return nil, fmt.Errorf("failed to add contest histories: %w", err)
 	}
+	s.contestsUpdated(ctx, student.ID)
 
 	return histories, nil
 }
 
-// GetLeaderboard retrieves the student leaderboard
-func (s *StudentService) GetLeaderboard(ctx context.Context, timeframe, department, batch string) ([]*models.Student, error) {
-	var start time.Time
-	now := time.Now()
-
-	switch timeframe {
-	case "week":
-		start = now.AddDate(0, 0, -7)
-	case "month":
-		start = now.AddDate(0, -1, 0)
-	case "year":
-		start = now.AddDate(-1, 0, 0)
-	default:
-		start = time.Time{} // All time
-	}
-
-	return s.repo.GetLeaderboard(ctx, start, department, batch)
-}
-
-// GetTrendingStudents retrieves trending students based on recent activity
-func (s *StudentService) GetTrendingStudents(ctx context.Context, days, limit int) ([]*models.Student, error) {
-	start := time.Now().AddDate(0, 0, -days)
-	return s.repo.GetTrendingStudents(ctx, start, limit)
-}
-
 // UpdateAllStudentStats updates LeetCode stats for all students
 func (s *StudentService) UpdateAllStudentStats(ctx context.Context) error {
 	students, err := s.repo.List(ctx, 1, 1000) // Get first 1000 students
@@ -341,6 +370,79 @@ func (s *StudentService) UpdateLeetCodeStats(ctx context.Context, id uint) (*mod
 	return s.GetLeetCodeStats(ctx, id)
 }
 
+// RefreshLeetCodeStats fetches fresh LeetCode stats for a student and stores them
+// along with a rating snapshot, only moving LastSolvedAt forward when the solved
+// count has actually grown
+func (s *StudentService) RefreshLeetCodeStats(ctx context.Context, id uint) (*models.LeetCodeStats, error) {
+	stats, err := s.GetLeetCodeStats(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.repo.GetLeetCodeStatsByStudentID(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load previous stats: %w", err)
+	}
+	if previous != nil {
+		stats.ID = previous.ID
+		stats.CreatedAt = previous.CreatedAt
+		stats.InitialRating = previous.InitialRating
+		if stats.TotalSolved <= previous.TotalSolved {
+			stats.LastSolvedAt = previous.LastSolvedAt
+		}
+	}
+
+	if err := s.repo.UpsertLeetCodeStats(ctx, stats); err != nil {
+		return nil, fmt.Errorf("failed to store LeetCode stats: %w", err)
+	}
+
+	// Every refresh is also a rating snapshot, so weekly stats, leaderboards
+	// and streaks follow the refresh schedule
+	if _, err := s.recordRating(ctx, id, stats); err != nil {
+		s.logger.Warn("Failed to record rating snapshot after refresh",
+			zap.Uint("student_id", id),
+			zap.Error(err),
+		)
+	}
+	if err := s.syncTags(ctx, id); err != nil {
+		s.logger.Warn("Failed to sync tag counts after refresh",
+			zap.Uint("student_id", id),
+			zap.Error(err),
+		)
+	}
+
+	return stats, nil
+}
+
+// syncTags stores the student's solve counts per LeetCode topic tag
+func (s *StudentService) syncTags(ctx context.Context, id uint) error {
+	student, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	tags, err := s.leetcodeService.GetTagProblemCounts(student.LeetcodeID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tag counts: %w", err)
+	}
+
+	now := time.Now()
+	stats := make([]models.StudentTagStat, 0, len(tags))
+	for _, tag := range tags {
+		stats = append(stats, models.StudentTagStat{
+			StudentID:      id,
+			TagSlug:        tag.Slug,
+			TagName:        tag.Name,
+			Level:          tag.Level,
+			ProblemsSolved: tag.ProblemsSolved,
+			UpdatedAt:      now,
+		})
+	}
+	if err := s.repo.ReplaceTagStats(ctx, id, stats); err != nil {
+		return fmt.Errorf("failed to store tag counts: %w", err)
+	}
+	return nil
+}
+
 // GetStudentWithStats retrieves a student with all their statistics
 func (s *StudentService) GetStudentWithStats(ctx context.Context, id uint) (*models.Student, error) {
 	student, err := s.repo.GetByIDWithRatings(ctx, id)
@@ -387,5 +489,51 @@ func (s *StudentService) AddContestHistories(ctx context.Context, studentID uint
 
 // UpdateStudentRating updates a student's rating
 func (s *StudentService) UpdateStudentRating(studentID uint, rating *models.Rating) error {
-	return s.repo.UpdateStudentRating(context.Background(), studentID, rating)
+	ctx := context.Background()
+	if err := s.repo.UpdateStudentRating(ctx, studentID, rating); err != nil {
+		return err
+	}
+	s.ratingRecorded(ctx, rating)
+	return nil
+}
+
+// SyncStudentRating fetches fresh LeetCode stats and records a new rating snapshot
+func (s *StudentService) SyncStudentRating(ctx context.Context, id uint) (*models.Rating, error) {
+	stats, err := s.GetLeetCodeStats(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.recordRating(ctx, id, stats)
+}
+
+// recordRating stores a rating snapshot derived from freshly fetched stats
+// and runs the rating hooks
+func (s *StudentService) recordRating(ctx context.Context, id uint, stats *models.LeetCodeStats) (*models.Rating, error) {
+	if stats.TotalSolved == 0 && stats.EasySolved == 0 && stats.MediumSolved == 0 && stats.HardSolved == 0 {
+		return nil, errors.New("received all zero values from LeetCode API")
+	}
+
+	now := time.Now()
+	rating := &models.Rating{
+		StudentID:     id,
+		ProblemsCount: stats.TotalSolved,
+		EasyCount:     stats.EasySolved,
+		MediumCount:   stats.MediumSolved,
+		HardCount:     stats.HardSolved,
+		GlobalRank:    stats.ContestRanking,
+		RecordedAt:    now,
+		CreatedAt:     now,
+	}
+
+	// Calculate rating using the formula: Easy(x1) + Medium(x3) + Hard(x5) + 20% of contest rating
+	problemRating := (rating.EasyCount * 1) + (rating.MediumCount * 3) + (rating.HardCount * 5)
+	contestBonus := int(stats.ContestRating * 0.2)
+	rating.Rating = problemRating + contestBonus
+
+	if err := s.repo.UpdateStudentRating(ctx, id, rating); err != nil {
+		return nil, fmt.Errorf("failed to store rating: %w", err)
+	}
+	s.ratingRecorded(ctx, rating)
+
+	return rating, nil
 }
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/models"
+	"github.com/ayush/ORBIT/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxBackfillWeeks bounds one backfill so a typo cannot recompute years
+const maxBackfillWeeks = 104
+
+// WeeklyStatsService derives weekly progress from rating snapshots, so a
+// week's numbers are what changed between its start and end rather than
+// lifetime totals
+type WeeklyStatsService struct {
+	students *StudentService
+	repo     *repository.StudentRepository
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+}
+
+func NewWeeklyStatsService(students *StudentService, logger *zap.Logger) *WeeklyStatsService {
+	return &WeeklyStatsService{
+		students: students,
+		repo:     students.repo,
+		calendar: students.calendar,
+		logger:   logger,
+	}
+}
+
+// ComputeWeek recomputes the stats of the week containing day for the given
+// students, or for every student when none are given, and returns how many
+// rows were written
+func (s *WeeklyStatsService) ComputeWeek(ctx context.Context, day time.Time, studentIDs ...uint) (int, error) {
+	start := s.calendar.WeekStart(day)
+	end := s.calendar.WeekEnd(day)
+
+	stats, err := s.repo.ComputeWeeklyStats(ctx, start, end, studentIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute weekly stats for week of %s: %w", s.calendar.FormatDate(start), err)
+	}
+	if err := s.repo.UpsertWeeklyStats(ctx, stats); err != nil {
+		return 0, fmt.Errorf("failed to store weekly stats for week of %s: %w", s.calendar.FormatDate(start), err)
+	}
+	return len(stats), nil
+}
+
+// Backfill recomputes every week from the one containing from through the
+// one containing to, and returns how many rows were written
+func (s *WeeklyStatsService) Backfill(ctx context.Context, from, to time.Time) (int, error) {
+	start := time.Now()
+	first := s.calendar.WeekStart(from)
+	last := s.calendar.WeekStart(to)
+	if last.Before(first) {
+		return 0, fmt.Errorf("backfill range ends before it starts")
+	}
+
+	var weeks []time.Time
+	for week := first; !week.After(last); week = s.calendar.WeekEnd(week) {
+		weeks = append(weeks, week)
+	}
+	if len(weeks) > maxBackfillWeeks {
+		return 0, fmt.Errorf("backfill range spans %d weeks, at most %d are allowed", len(weeks), maxBackfillWeeks)
+	}
+
+	total := 0
+	for _, week := range weeks {
+		written, err := s.ComputeWeek(ctx, week)
+		if err != nil {
+			return total, err
+		}
+		total += written
+	}
+
+	s.logger.Info("Weekly stats backfilled",
+		zap.String("service", "WeeklyStatsService"),
+		zap.Time("from", first),
+		zap.Time("to", last),
+		zap.Int("weeks", len(weeks)),
+		zap.Int("rows", total),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return total, nil
+}
+
+// RecordRating recomputes the student's stats for the week of a new snapshot,
+// and for the following week when it has started, since that week's baseline
+// may be the new snapshot
+func (s *WeeklyStatsService) RecordRating(ctx context.Context, rating *models.Rating) error {
+	if _, err := s.ComputeWeek(ctx, rating.RecordedAt, rating.StudentID); err != nil {
+		return err
+	}
+	next := s.calendar.WeekEnd(rating.RecordedAt)
+	if next.After(s.calendar.Now()) {
+		return nil
+	}
+	_, err := s.ComputeWeek(ctx, next, rating.StudentID)
+	return err
+}
+
+// SyncStudent records a fresh rating snapshot from LeetCode and returns the
+// student's stats for the current week
+func (s *WeeklyStatsService) SyncStudent(ctx context.Context, id uint) (*models.WeeklyStats, error) {
+	if _, err := s.students.SyncStudentRating(ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := s.ComputeWeek(ctx, s.calendar.Now(), id); err != nil {
+		return nil, err
+	}
+	return s.CurrentWeek(ctx, id)
+}
+
+// CurrentWeek returns the student's stats for the current week
+func (s *WeeklyStatsService) CurrentWeek(ctx context.Context, id uint) (*models.WeeklyStats, error) {
+	now := s.calendar.Now()
+	stats, err := s.repo.GetWeeklyStats(ctx, id, s.calendar.WeekStart(now), s.calendar.WeekEnd(now))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get weekly stats: %w", err)
+	}
+	return stats, nil
+}
+
+// TrackWeeklyStats keeps weekly stats current with every rating snapshot the
+// student service stores
+func TrackWeeklyStats(students *StudentService, weekly *WeeklyStatsService, logger *zap.Logger) {
+	students.OnRatingRecorded(func(ctx context.Context, rating *models.Rating) {
+		if err := weekly.RecordRating(ctx, rating); err != nil {
+			logger.Error("Failed to update weekly stats",
+				zap.Uint("student_id", rating.StudentID),
+				zap.Error(err),
+			)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
+)
+
+// StreakWorker recalculates every streak at the start of each institution
+// day. Syncs keep the streaks of active students current; this lets streaks
+// lapse and active-day windows slide for students who did not sync.
+type StreakWorker struct {
+	streaks  *service.StreakService
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewStreakWorker creates a new StreakWorker
+func NewStreakWorker(streaks *service.StreakService, cal *calendar.Calendar, logger *zap.Logger) *StreakWorker {
+	return &StreakWorker{
+		streaks:  streaks,
+		calendar: cal,
+		logger:   logger,
+	}
+}
+
+// Start begins waiting for day boundaries
+func (w *StreakWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.logger.Info("Streak worker started",
+		zap.String("timezone", w.calendar.Timezone()))
+
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+func (w *StreakWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	w.logger.Info("Streak worker stopped")
+}
+
+func (w *StreakWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	for {
+		now := w.calendar.Now()
+		next := w.calendar.StartOfDay(now).AddDate(0, 0, 1)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := w.streaks.RecalculateAll(ctx); err != nil && ctx.Err() == nil {
+				w.logger.Error("Failed to recalculate streaks", zap.Error(err))
+			}
+		}
+	}
+}
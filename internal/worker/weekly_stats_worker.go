@@ -1,94 +1,93 @@
 package worker
 
 import (
-	"log"
+	"context"
+	"sync"
 	"time"
 
-	"github.com/ayush/ORBIT/internal/models"
-	"github.com/ayush/ORBIT/pkg/leetcode"
+	"github.com/ayush/ORBIT/internal/calendar"
+	"github.com/ayush/ORBIT/internal/service"
+	"go.uber.org/zap"
 )
 
-type StudentDB interface {
-	GetAllStudents() ([]*models.Student, error)
-}
-
-type WeeklyStatsDB interface {
-	CreateWeeklyStats(stats *models.WeeklyStats) error
-	GetWeeklyStats(studentID string, startDate, endDate time.Time) (*models.WeeklyStats, error)
-}
-
-// WeeklyStatsWorker handles periodic updates of student LeetCode statistics
+// WeeklyStatsWorker closes out each week's stats at the institution's week
+// boundary. Ratings keep the current week up to date as they arrive; this
+// recomputes the week that just ended for everyone, including students who
+// had no sync since their last snapshot.
 type WeeklyStatsWorker struct {
-	studentDB StudentDB
-	statsDB   WeeklyStatsDB
-	lc        *leetcode.Client
+	weekly   *service.WeeklyStatsService
+	calendar *calendar.Calendar
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // NewWeeklyStatsWorker creates a new WeeklyStatsWorker
-func NewWeeklyStatsWorker(studentDB StudentDB, statsDB WeeklyStatsDB, lc *leetcode.Client) *WeeklyStatsWorker {
+func NewWeeklyStatsWorker(weekly *service.WeeklyStatsService, cal *calendar.Calendar, logger *zap.Logger) *WeeklyStatsWorker {
 	return &WeeklyStatsWorker{
-		studentDB: studentDB,
-		statsDB:   statsDB,
-		lc:        lc,
+		weekly:   weekly,
+		calendar: cal,
+		logger:   logger,
 	}
 }
 
-// Start begins the weekly stats update process
+// Start begins waiting for week boundaries
 func (w *WeeklyStatsWorker) Start() {
-	ticker := time.NewTicker(7 * 24 * time.Hour) // Run every week
-	go func() {
-		for {
-			<-ticker.C
-			w.UpdateAllStudentsStats()
-		}
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.logger.Info("Weekly stats worker started",
+		zap.String("timezone", w.calendar.Timezone()),
+		zap.Stringer("week_start", w.calendar.WeekStartDay()))
+
+	w.wg.Add(1)
+	go w.run(ctx)
 }
 
-// UpdateAllStudentsStats updates the weekly statistics for all students
-func (w *WeeklyStatsWorker) UpdateAllStudentsStats() {
-	students, err := w.studentDB.GetAllStudents()
-	if err != nil {
-		log.Printf("Failed to get students: %v", err)
-		return
+func (w *WeeklyStatsWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
 	}
+	w.wg.Wait()
+	w.logger.Info("Weekly stats worker stopped")
+}
 
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -7)
+func (w *WeeklyStatsWorker) run(ctx context.Context) {
+	defer w.wg.Done()
 
-	for _, student := range students {
-		if student.LeetcodeID == "" {
-			continue
+	for {
+		// Timers are re-armed from the calendar each week, so weeks that are
+		// not 168 hours long around DST changes still close on time
+		now := w.calendar.Now()
+		timer := time.NewTimer(w.calendar.WeekEnd(now).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			w.closeWeek(ctx)
 		}
+	}
+}
+
+// closeWeek recomputes the week that just ended and starts the new one
+func (w *WeeklyStatsWorker) closeWeek(ctx context.Context) {
+	current := w.calendar.WeekStart(w.calendar.Now())
+	previous := w.calendar.WeekStart(current.AddDate(0, 0, -1))
 
-		stats, err := w.lc.GetUserStats(student.LeetcodeID)
+	for _, week := range []time.Time{previous, current} {
+		count, err := w.weekly.ComputeWeek(ctx, week)
 		if err != nil {
-			log.Printf("Failed to get stats for student %s: %v", student.StudentID, err)
+			if ctx.Err() == nil {
+				w.logger.Error("Failed to compute weekly stats",
+					zap.String("week_start", w.calendar.FormatDate(week)),
+					zap.Error(err))
+			}
 			continue
 		}
-
-		weeklyStats := &models.WeeklyStats{
-			StudentID:     student.StudentID,
-			WeekStartDate: weekStart,
-			WeekEndDate:   now,
-			EasyCount:     stats.EasyCount,
-			MediumCount:   stats.MediumCount,
-			HardCount:     stats.HardCount,
-			ProblemsCount: stats.TotalSolved,
-			ContestRating: stats.ContestRating,
-			GlobalRanking: stats.ContestRanking,
-		}
-
-		// Check if stats already exist for this week
-		existing, err := w.statsDB.GetWeeklyStats(student.StudentID, weekStart, now)
-		if err == nil && existing != nil {
-			continue // Skip if stats already exist for this week
-		}
-
-		if err := w.statsDB.CreateWeeklyStats(weeklyStats); err != nil {
-			log.Printf("Failed to save stats for student %s: %v", student.StudentID, err)
-		}
-
-		// Add delay to avoid rate limiting
-		time.Sleep(500 * time.Millisecond)
+		w.logger.Info("Weekly stats computed",
+			zap.String("week_start", w.calendar.FormatDate(week)),
+			zap.Int("students", count))
 	}
 }
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_leetcode_stats_last_solved_at;
+DROP INDEX IF EXISTS idx_leetcode_stats_updated_at;
+
+ALTER TABLE leetcode_stats
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS contest_ranking;
@@ -0,0 +1,8 @@
+-- Columns written by the stats refresh queue
+ALTER TABLE leetcode_stats
+    ADD COLUMN IF NOT EXISTS contest_ranking INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ DEFAULT NOW();
+
+-- The refresh queue orders students by staleness and recent activity
+CREATE INDEX IF NOT EXISTS idx_leetcode_stats_updated_at ON leetcode_stats(updated_at);
+CREATE INDEX IF NOT EXISTS idx_leetcode_stats_last_solved_at ON leetcode_stats(last_solved_at);
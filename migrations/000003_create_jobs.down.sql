@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_jobs_updated_at ON jobs;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,24 @@
+-- Durable background job queue. Workers claim rows with
+-- SELECT ... FOR UPDATE SKIP LOCKED and hold them until locked_until.
+CREATE TABLE jobs (
+    id              BIGSERIAL PRIMARY KEY,
+    type            VARCHAR(100) NOT NULL,
+    payload         JSONB NOT NULL DEFAULT '{}',
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, running, completed, dead
+    attempts        INT NOT NULL DEFAULT 0,
+    max_attempts    INT NOT NULL DEFAULT 5,
+    run_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    last_error      TEXT,
+    completed_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_claim ON jobs(status, run_at);
+CREATE INDEX idx_jobs_locked_until ON jobs(locked_until) WHERE status = 'running';
+
+CREATE TRIGGER update_jobs_updated_at
+    BEFORE UPDATE ON jobs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+ALTER TABLE file_uploads
+    DROP COLUMN IF EXISTS column_mapping,
+    DROP COLUMN IF EXISTS uploaded_by,
+    DROP COLUMN IF EXISTS error_details,
+    DROP COLUMN IF EXISTS failed_records,
+    DROP COLUMN IF EXISTS successful_records,
+    DROP COLUMN IF EXISTS success_records,
+    DROP COLUMN IF EXISTS total_records,
+    DROP COLUMN IF EXISTS storage_path,
+    DROP COLUMN IF EXISTS original_name;
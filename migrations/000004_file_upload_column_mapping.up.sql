@@ -0,0 +1,13 @@
+-- Bring file_uploads in line with the upload model used by the import pipeline
+ALTER TABLE file_uploads
+    ADD COLUMN IF NOT EXISTS original_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS storage_path TEXT,
+    ADD COLUMN IF NOT EXISTS total_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS success_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS successful_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS failed_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS error_details TEXT,
+    ADD COLUMN IF NOT EXISTS uploaded_by BIGINT REFERENCES students(id) ON DELETE SET NULL;
+
+-- Optional header mapping sent with the upload
+ALTER TABLE file_uploads ADD COLUMN IF NOT EXISTS column_mapping JSONB;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS import_rows;
+
+ALTER TABLE file_uploads
+    DROP COLUMN IF EXISTS import_mode,
+    DROP COLUMN IF EXISTS created_records,
+    DROP COLUMN IF EXISTS updated_records,
+    DROP COLUMN IF EXISTS unchanged_records;
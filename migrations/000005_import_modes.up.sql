@@ -0,0 +1,19 @@
+ALTER TABLE file_uploads
+    ADD COLUMN IF NOT EXISTS import_mode VARCHAR(20) NOT NULL DEFAULT 'insert',
+    ADD COLUMN IF NOT EXISTS created_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS updated_records INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS unchanged_records INT NOT NULL DEFAULT 0;
+
+-- Outcome of every data row of an upload
+CREATE TABLE import_rows (
+    id              BIGSERIAL PRIMARY KEY,
+    file_upload_id  BIGINT NOT NULL REFERENCES file_uploads(id) ON DELETE CASCADE,
+    row_number      INT NOT NULL,
+    student_id      VARCHAR(50),
+    student_ref_id  BIGINT REFERENCES students(id) ON DELETE SET NULL,
+    outcome         VARCHAR(20) NOT NULL,
+    error           TEXT,
+    created_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_import_rows_upload ON import_rows(file_upload_id, row_number);
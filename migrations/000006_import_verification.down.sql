@@ -0,0 +1,5 @@
+ALTER TABLE import_rows DROP COLUMN IF EXISTS warning;
+
+ALTER TABLE file_uploads
+    DROP COLUMN IF EXISTS verify_policy,
+    DROP COLUMN IF EXISTS flagged_records;
@@ -0,0 +1,5 @@
+ALTER TABLE file_uploads
+    ADD COLUMN IF NOT EXISTS verify_policy VARCHAR(10) NOT NULL DEFAULT 'off',
+    ADD COLUMN IF NOT EXISTS flagged_records INT NOT NULL DEFAULT 0;
+
+ALTER TABLE import_rows ADD COLUMN IF NOT EXISTS warning TEXT;
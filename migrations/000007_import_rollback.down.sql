@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_logs;
+
+ALTER TABLE import_rows DROP COLUMN IF EXISTS previous;
+ALTER TABLE file_uploads DROP COLUMN IF EXISTS rolled_back_at;
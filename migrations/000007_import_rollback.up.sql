@@ -0,0 +1,16 @@
+ALTER TABLE file_uploads ADD COLUMN IF NOT EXISTS rolled_back_at TIMESTAMPTZ;
+
+-- Student values replaced by an update, restored on rollback
+ALTER TABLE import_rows ADD COLUMN IF NOT EXISTS previous JSONB;
+
+CREATE TABLE audit_logs (
+    id          BIGSERIAL PRIMARY KEY,
+    action      VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id   BIGINT NOT NULL,
+    actor       VARCHAR(100),
+    details     JSONB,
+    created_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_entity ON audit_logs(entity_type, entity_id);
@@ -0,0 +1,12 @@
+-- Rendered weekly digests kept for later retrieval
+CREATE TABLE weekly_reports (
+    id          BIGSERIAL PRIMARY KEY,
+    batch       VARCHAR(50) NOT NULL DEFAULT '',
+    department  VARCHAR(100) NOT NULL DEFAULT '',
+    week_start  DATE NOT NULL,
+    week_end    DATE NOT NULL,
+    html        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_weekly_reports_scope ON weekly_reports(batch, department, week_start DESC);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_system_stats_created;
+DROP INDEX IF EXISTS idx_department_stats_department_created;
+DROP INDEX IF EXISTS idx_batch_stats_batch_created;
+
+-- Keep only the latest snapshot so the unique constraints can be restored
+DELETE FROM batch_stats a USING batch_stats b
+    WHERE a.batch = b.batch AND (a.created_at, a.id) < (b.created_at, b.id);
+DELETE FROM department_stats a USING department_stats b
+    WHERE a.department = b.department AND (a.created_at, a.id) < (b.created_at, b.id);
+
+ALTER TABLE batch_stats ADD CONSTRAINT batch_stats_batch_key UNIQUE (batch);
+ALTER TABLE department_stats ADD CONSTRAINT department_stats_department_key UNIQUE (department);
@@ -0,0 +1,8 @@
+-- The aggregation job appends a snapshot per batch and department on every
+-- run, so the latest row is the current value and older rows are history
+ALTER TABLE batch_stats DROP CONSTRAINT IF EXISTS batch_stats_batch_key;
+ALTER TABLE department_stats DROP CONSTRAINT IF EXISTS department_stats_department_key;
+
+CREATE INDEX IF NOT EXISTS idx_batch_stats_batch_created ON batch_stats(batch, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_department_stats_department_created ON department_stats(department, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_system_stats_created ON system_stats(created_at DESC);
@@ -0,0 +1,13 @@
+UPDATE weekly_stats SET
+    problems_solved = problems_count,
+    easy_solved = easy_count,
+    medium_solved = medium_count,
+    hard_solved = hard_count;
+
+ALTER TABLE weekly_stats
+    DROP COLUMN IF EXISTS contest_rating,
+    DROP COLUMN IF EXISTS global_ranking,
+    DROP COLUMN IF EXISTS problems_count,
+    DROP COLUMN IF EXISTS easy_count,
+    DROP COLUMN IF EXISTS medium_count,
+    DROP COLUMN IF EXISTS hard_count;
@@ -0,0 +1,21 @@
+-- Weekly stats are computed from rating snapshots: the *_solved columns hold
+-- what was solved during the week and the *_count columns the totals at its end
+ALTER TABLE weekly_stats
+    ADD COLUMN IF NOT EXISTS contest_rating FLOAT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS global_ranking INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS problems_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS easy_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS medium_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS hard_count INT NOT NULL DEFAULT 0;
+
+-- Rows written before this change hold lifetime totals as weekly progress.
+-- Keep them as end-of-week totals; the backfill job recomputes the progress.
+UPDATE weekly_stats SET
+    problems_count = problems_solved,
+    easy_count = easy_solved,
+    medium_count = medium_solved,
+    hard_count = hard_solved,
+    problems_solved = 0,
+    easy_solved = 0,
+    medium_solved = 0,
+    hard_solved = 0;
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS student_streaks;
+
+-- Detach the default partition rather than dropping it, then move its rows
+-- into monthly partitions named like the ones in the initial schema so no
+-- daily progress is lost
+ALTER TABLE daily_progress DETACH PARTITION daily_progress_default;
+
+DO $$
+DECLARE
+    month DATE;
+BEGIN
+    FOR month IN
+        SELECT DISTINCT date_trunc('month', date)::date FROM daily_progress_default
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF daily_progress FOR VALUES FROM (%L) TO (%L)',
+            'daily_progress_' || to_char(month, '"y"YYYY"m"MM'),
+            month,
+            (month + INTERVAL '1 month')::date
+        );
+    END LOOP;
+END $$;
+
+INSERT INTO daily_progress SELECT * FROM daily_progress_default;
+
+DROP TABLE daily_progress_default;
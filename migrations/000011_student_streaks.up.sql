@@ -0,0 +1,15 @@
+-- daily_progress only had partitions for early 2024; rows for other months
+-- go to a default partition until dedicated ones are added
+CREATE TABLE IF NOT EXISTS daily_progress_default PARTITION OF daily_progress DEFAULT;
+
+-- Streak and consistency metrics, recalculated from daily_progress
+CREATE TABLE IF NOT EXISTS student_streaks (
+    student_id          BIGINT PRIMARY KEY REFERENCES students(id) ON DELETE CASCADE,
+    current_streak      INT NOT NULL DEFAULT 0,
+    longest_streak      INT NOT NULL DEFAULT 0,
+    active_days_30      INT NOT NULL DEFAULT 0,
+    active_days_90      INT NOT NULL DEFAULT 0,
+    consistency_score   FLOAT NOT NULL DEFAULT 0,
+    last_active_date    DATE,
+    updated_at          TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS at_risk_flags;
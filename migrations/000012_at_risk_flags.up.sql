@@ -0,0 +1,17 @@
+-- Disengagement flags raised by the inactivity detector, one row per reason
+CREATE TABLE IF NOT EXISTS at_risk_flags (
+    id              BIGSERIAL PRIMARY KEY,
+    student_id      BIGINT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    reason          VARCHAR(50) NOT NULL,
+    detail          TEXT NOT NULL DEFAULT '',
+    flagged_at      TIMESTAMPTZ NOT NULL,
+    checked_at      TIMESTAMPTZ NOT NULL,
+    resolved_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- A reason is open at most once per student
+CREATE UNIQUE INDEX IF NOT EXISTS idx_at_risk_flags_open
+    ON at_risk_flags(student_id, reason) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_at_risk_flags_student_id ON at_risk_flags(student_id);
@@ -0,0 +1 @@
+ALTER TABLE daily_progress DROP COLUMN IF EXISTS submissions;
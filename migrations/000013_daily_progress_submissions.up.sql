@@ -0,0 +1,3 @@
+-- Submissions per day from the LeetCode submission calendar, which places
+-- activity on the right day even when stats are synced less than daily
+ALTER TABLE daily_progress ADD COLUMN IF NOT EXISTS submissions INT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_contest_history_student_date;
+
+ALTER TABLE contest_history
+    ALTER COLUMN rating TYPE INT USING rating::INT,
+    DROP COLUMN IF EXISTS finish_time_seconds,
+    DROP COLUMN IF EXISTS contest_title;
+
+DROP TABLE IF EXISTS ratings;
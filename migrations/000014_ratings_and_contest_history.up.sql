@@ -0,0 +1,28 @@
+-- The rating and contest syncs write ratings snapshots and contest_history
+-- columns the initial schema never created; this brings the schema in line
+-- with those syncs
+
+-- Rating snapshots written by the rating sync
+CREATE TABLE IF NOT EXISTS ratings (
+    id              BIGSERIAL PRIMARY KEY,
+    student_id      BIGINT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    rating          INT NOT NULL DEFAULT 0,
+    problems_count  INT NOT NULL DEFAULT 0,
+    easy_count      INT NOT NULL DEFAULT 0,
+    medium_count    INT NOT NULL DEFAULT 0,
+    hard_count      INT NOT NULL DEFAULT 0,
+    global_rank     INT NOT NULL DEFAULT 0,
+    recorded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ratings_student_recorded ON ratings(student_id, recorded_at DESC);
+
+-- Align contest_history with the columns written by the contest sync
+ALTER TABLE contest_history
+    ADD COLUMN IF NOT EXISTS contest_title VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS finish_time_seconds BIGINT NOT NULL DEFAULT 0,
+    ALTER COLUMN contest_name DROP NOT NULL,
+    ALTER COLUMN rating TYPE FLOAT;
+
+CREATE INDEX IF NOT EXISTS idx_contest_history_student_date ON contest_history(student_id, contest_date DESC);
@@ -0,0 +1,3 @@
+-- Student values written by a create or update, compared on rollback so
+-- later edits are not overwritten
+ALTER TABLE import_rows ADD COLUMN IF NOT EXISTS applied JSONB;
@@ -0,0 +1,10 @@
+-- Problems solved per LeetCode topic tag, replaced on every stats refresh
+CREATE TABLE IF NOT EXISTS student_tag_stats (
+    student_id      BIGINT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    tag_slug        VARCHAR(100) NOT NULL,
+    tag_name        VARCHAR(100) NOT NULL,
+    level           VARCHAR(20) NOT NULL,
+    problems_solved INT NOT NULL DEFAULT 0,
+    updated_at      TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (student_id, tag_slug)
+);
@@ -0,0 +1,24 @@
+-- Detach the default partition rather than dropping it, then move its rows
+-- into yearly partitions named like the one in the initial schema so no
+-- contest history is lost
+ALTER TABLE contest_history DETACH PARTITION contest_history_default;
+
+DO $$
+DECLARE
+    year DATE;
+BEGIN
+    FOR year IN
+        SELECT DISTINCT date_trunc('year', contest_date)::date FROM contest_history_default
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF contest_history FOR VALUES FROM (%L) TO (%L)',
+            'contest_history_' || to_char(year, 'YYYY'),
+            year,
+            (year + INTERVAL '1 year')::date
+        );
+    END LOOP;
+END $$;
+
+INSERT INTO contest_history SELECT * FROM contest_history_default;
+
+DROP TABLE contest_history_default;
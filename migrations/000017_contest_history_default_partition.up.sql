@@ -0,0 +1,3 @@
+-- contest_history only had a partition for 2024; contests on other dates,
+-- including those from before a student joined, go to a default partition
+CREATE TABLE IF NOT EXISTS contest_history_default PARTITION OF contest_history DEFAULT;
@@ -3,23 +3,46 @@ package routes
 import (
 	"github.com/ayush/ORBIT/handlers"
 	"github.com/ayush/ORBIT/internal/cache"
+	"github.com/ayush/ORBIT/internal/calendar"
 	"github.com/ayush/ORBIT/internal/database"
 	"github.com/ayush/ORBIT/internal/service"
-	"github.com/ayush/ORBIT/pkg/leetcode"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func SetupRoutes(r *gin.Engine, db *database.StudentDB) {
+func SetupRoutes(r *gin.Engine, db *database.StudentDB, cal *calendar.Calendar, redisCache *cache.RedisCache, leaderboardStream *service.LeaderboardStream, aliases service.ColumnAliases, refresher handlers.RefreshRequester, jobs handlers.JobEnqueuer) {
 	// Initialize dependencies
 	logger, _ := zap.NewProduction()
-	redisCache := cache.NewRedisCache("localhost:6379", "", 0)
-	studentService := service.NewStudentService(db.StudentRepository(), logger)
+	studentService := service.NewStudentService(db.StudentRepository(), cal, logger)
+	leaderboardStore := service.NewLeaderboardStore(redisCache, studentService, logger)
+	service.TrackLeaderboards(studentService, leaderboardStore, logger)
+	weeklyStatsService := service.NewWeeklyStatsService(studentService, logger)
+	service.TrackWeeklyStats(studentService, weeklyStatsService, logger)
+	streakService := service.NewStreakService(studentService, logger)
+	service.TrackStreaks(studentService, streakService, logger)
+	importService := service.NewImportService(studentService, aliases, nil, logger)
+	exportService := service.NewExportService(studentService, logger)
+	reportService := service.NewReportService(studentService, logger)
+	analyticsService := service.NewAnalyticsService(studentService, logger)
+	riskService := service.NewRiskService(studentService, logger)
+	forecastService := service.NewForecastService(studentService, logger)
+	cohortService := service.NewCohortService(studentService, logger)
+	distributionService := service.NewDistributionService(studentService, logger)
+	comparisonService := service.NewComparisonService(studentService, logger)
+	leaderboardService := service.NewLeaderboardService(studentService, logger)
 
 	// Initialize handlers
-	studentHandler := handlers.NewHandler(studentService, redisCache, logger)
-	leetcodeClient := leetcode.NewClient()
-	weeklyStatsHandler := handlers.NewWeeklyStatsHandler(db.WeeklyStatsRepository(), leetcodeClient, logger)
+	studentHandler := handlers.NewHandler(studentService, importService, redisCache, refresher, jobs, logger)
+	uploadHandler := handlers.NewUploadHandler(importService, logger)
+	exportHandler := handlers.NewExportHandler(exportService, logger)
+	reportHandler := handlers.NewReportHandler(reportService, cal, logger)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, riskService, distributionService, logger)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, leaderboardStore, leaderboardStream, cal, logger)
+	weeklyStatsHandler := handlers.NewWeeklyStatsHandler(db.WeeklyStatsRepository(), weeklyStatsService, jobs, cal, logger)
+	studentStatsHandler := handlers.NewStudentStatsHandler(streakService, distributionService, logger)
+	forecastHandler := handlers.NewForecastHandler(forecastService, cal, logger)
+	cohortHandler := handlers.NewCohortHandler(cohortService, logger)
+	comparisonHandler := handlers.NewComparisonHandler(comparisonService, logger)
 
 	api := r.Group("/api/v1")
 	{
@@ -27,11 +50,42 @@ func SetupRoutes(r *gin.Engine, db *database.StudentDB) {
 		api.GET("/students", studentHandler.GetAllStudents)
 		api.POST("/students", studentHandler.CreateStudent)
 		api.POST("/students/bulk", studentHandler.BulkCreateStudents)
+		api.GET("/students/export", exportHandler.ExportStudents)
+		api.GET("/students/compare", comparisonHandler.CompareStudents)
 		api.GET("/students/:id", studentHandler.GetStudentDetails)
+		api.GET("/students/:id/stats", studentStatsHandler.GetStudentStats)
+		api.GET("/students/:id/forecast", forecastHandler.GetStudentForecast)
+		api.PUT("/students/ratings/update-all", studentHandler.UpdateAllStudentRatings)
+		api.PUT("/students/contest-history/update-all", studentHandler.UpdateAllContestHistories)
+
+		// Upload routes
+		api.GET("/uploads", uploadHandler.ListUploads)
+		api.GET("/uploads/:id", uploadHandler.GetUpload)
+		api.GET("/uploads/:id/errors.csv", uploadHandler.GetUploadErrors)
+		api.POST("/uploads/:id/rollback", uploadHandler.RollbackUpload)
+
+		// Report routes
+		api.GET("/reports", reportHandler.ListReports)
+		api.GET("/reports/weekly", reportHandler.WeeklyReport)
+		api.GET("/reports/:id", reportHandler.GetReport)
+
+		// Analytics routes
+		api.GET("/analytics/department/:dept", analyticsHandler.GetDepartmentStats)
+		api.GET("/analytics/batch/:batch", analyticsHandler.GetBatchStats)
+		api.GET("/analytics/at-risk", analyticsHandler.GetAtRiskStudents)
+		api.GET("/analytics/forecast/batch/:batch", forecastHandler.GetBatchForecast)
+		api.GET("/analytics/cohorts", cohortHandler.CompareCohorts)
+		api.GET("/analytics/distribution", analyticsHandler.GetDistribution)
+		api.GET("/analytics/leaderboard", leaderboardHandler.GetLeaderboard)
+		api.GET("/analytics/leaderboard/top", leaderboardHandler.GetTopStudents)
+		api.GET("/analytics/leaderboard/rank/:id", leaderboardHandler.GetStudentRank)
+		api.GET("/analytics/leaderboard/stream", leaderboardHandler.StreamLeaderboard)
+		api.GET("/analytics/trending", leaderboardHandler.GetTrendingStudents)
 
 		// Weekly stats routes
 		api.GET("/students/:id/weekly-stats", weeklyStatsHandler.GetStudentWeeklyStats)
 		api.PUT("/students/:id/weekly-stats", weeklyStatsHandler.UpdateWeeklyStats)
 		api.PUT("/students/weekly-stats/update-all", weeklyStatsHandler.UpdateAllWeeklyStats)
+		api.POST("/students/weekly-stats/backfill", weeklyStatsHandler.BackfillWeeklyStats)
 	}
 }